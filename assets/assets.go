@@ -0,0 +1,199 @@
+// assets/assets.go
+package assets
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/gocql/gocql"
+)
+
+// Kind - video assetlari turi; API manzilidagi segment ham shu
+type Kind string
+
+const (
+	Renditions Kind = "renditions" // progressive MP4 sifatlar
+	HLS        Kind = "hls"        // master.m3u8, <sifat>/index.m3u8, segmentlar
+	DASH       Kind = "dash"       // manifest.mpd va fMP4 segmentlar
+	Audio      Kind = "audio"      // audio-only renditionlar
+	Thumbnails Kind = "thumbnails" // thumbnail variantlari va custom rasmlar
+	Sprites    Kind = "sprites"    // seek bar preview (sprite sheet + WebVTT)
+)
+
+var ErrInvalidAsset = errors.New("noto'g'ri asset manzili")
+
+// urlPrefix - API orqali beriladigan asset manzillari boshi (URL() bilan bir xil)
+const urlPrefix = "/api/videos/"
+
+// legacyURL - asset resolverdan oldin bazaga yozilgan manzillar:
+// /videos/<id>/<sifat> (MP4 rendition) va /thumbnails/<id>/thumbnail.jpg
+var legacyURL = regexp.MustCompile(`^/(videos|thumbnails)/([0-9a-fA-F-]{36})/([^/]+)$`)
+
+// filePatterns har bir tur uchun ruxsat etilgan fayl nomlari (path traversal oldini olish uchun).
+// master.m3u8 va manifest.mpd bu yerda yo'q: ular video manzili ostida alohida
+// marshrut bilan beriladi (ichidagi nisbiy yo'llar shunga moslangan).
+var filePatterns = map[Kind]*regexp.Regexp{
+	Renditions: regexp.MustCompile(`^[0-9]+p\.mp4$`),
+	HLS:        regexp.MustCompile(`^([0-9]+p|audio)/[A-Za-z0-9_-]+\.(m3u8|ts)$`),
+	DASH:       regexp.MustCompile(`^[A-Za-z0-9_-]+\.m4s$`),
+	Audio:      regexp.MustCompile(`^[a-z0-9-]+\.(m4a|opus)$`),
+	Thumbnails: regexp.MustCompile(`^((candidate|custom)-[0-9]+|thumbnail)\.jpg$`),
+	Sprites:    regexp.MustCompile(`^(sprite-[0-9]{3}\.jpg|thumbnails\.vtt)$`),
+}
+
+// Asset - videoning bitta fayli. Mantiqiy manzil (URL) va storage kaliti
+// faqat shu yerda hisoblanadi: URL yaratadigan va fayl beradigan kod bir xil
+// qoidadan foydalanadi.
+type Asset struct {
+	VideoID gocql.UUID
+	Kind    Kind
+	File    string // tur ichidagi nisbiy yo'l, masalan "720p/index.m3u8"
+}
+
+// Of ichki kod uchun asset (nom tekshirilmaydi)
+func Of(videoID gocql.UUID, kind Kind, file string) Asset {
+	return Asset{VideoID: videoID, Kind: kind, File: file}
+}
+
+// Parse so'rovdagi tur va fayl nomini tekshirib asset qaytaradi
+func Parse(videoID gocql.UUID, kind Kind, file string) (Asset, error) {
+	pattern, ok := filePatterns[kind]
+	if !ok || !pattern.MatchString(file) {
+		return Asset{}, ErrInvalidAsset
+	}
+	return Of(videoID, kind, file), nil
+}
+
+// Rendition sifat nomi bo'yicha MP4 asset
+func Rendition(videoID gocql.UUID, quality string) Asset {
+	return Of(videoID, Renditions, quality+".mp4")
+}
+
+// URL API orqali mantiqiy manzil
+func (a Asset) URL() string {
+	return fmt.Sprintf("%s%s/%s/%s", urlPrefix, a.VideoID, a.Kind, a.File)
+}
+
+// ParseURL URL() qaytargan manzildan assetni tiklaydi
+func ParseURL(url string) (Asset, error) {
+	rest, ok := strings.CutPrefix(url, urlPrefix)
+	if !ok {
+		return Asset{}, ErrInvalidAsset
+	}
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) != 3 {
+		return Asset{}, ErrInvalidAsset
+	}
+	videoID, err := gocql.ParseUUID(parts[0])
+	if err != nil {
+		return Asset{}, ErrInvalidAsset
+	}
+	return Parse(videoID, Kind(parts[1]), parts[2])
+}
+
+// RewriteLegacyURL eski formatdagi manzilni asset URL ga aylantiradi. Storage
+// kalitlari o'zgarmagan (Key), shuning uchun eski videolar yangi marshrut
+// orqali beriladi. Boshqa manzillar o'zgarishsiz qaytariladi.
+func RewriteLegacyURL(url string) string {
+	m := legacyURL.FindStringSubmatch(url)
+	if m == nil {
+		return url
+	}
+	videoID, err := gocql.ParseUUID(m[2])
+	if err != nil {
+		return url
+	}
+
+	var asset Asset
+	if m[1] == "videos" {
+		asset, err = Parse(videoID, Renditions, m[3]+".mp4")
+	} else {
+		asset, err = Parse(videoID, Thumbnails, m[3])
+	}
+	if err != nil {
+		return url
+	}
+	return asset.URL()
+}
+
+// Bucket asset saqlanadigan bucket
+func (a Asset) Bucket(buckets config.Buckets) string {
+	switch a.Kind {
+	case Thumbnails, Sprites:
+		return buckets.Thumbnails
+	default:
+		return buckets.Processed
+	}
+}
+
+// Key storage kaliti
+func (a Asset) Key() string {
+	if a.Kind == Renditions {
+		// Mavjud obyektlar bilan moslik: processed/<id>/<id>-<sifat>.mp4
+		return fmt.Sprintf("processed/%s/%s-%s", a.VideoID, a.VideoID, a.File)
+	}
+	return path.Join(Prefix(a.VideoID, a.Kind), a.File)
+}
+
+// Prefix tur fayllari saqlanadigan storage prefiksi (papkani yuklash uchun)
+func Prefix(videoID gocql.UUID, kind Kind) string {
+	switch kind {
+	case Thumbnails:
+		return videoID.String()
+	case Sprites:
+		return path.Join(videoID.String(), "sprites")
+	default:
+		return path.Join("processed", videoID.String(), string(kind))
+	}
+}
+
+// ContentType fayl kengaytmasi bo'yicha
+func (a Asset) ContentType() string {
+	return ContentTypeFor(a.File)
+}
+
+// CacheControl asset turi bo'yicha kesh siyosati. Playlist va manifestlar
+// qayta yozilishi mumkin (masalan master.m3u8 ga audio varianti qo'shiladi),
+// segmentlar va custom thumbnaillar esa hech qachon o'zgarmaydi.
+func (a Asset) CacheControl() string {
+	switch path.Ext(a.File) {
+	case ".m3u8", ".mpd", ".vtt":
+		return "public, max-age=60"
+	case ".ts", ".m4s":
+		return "public, max-age=31536000, immutable"
+	}
+	if a.Kind == Thumbnails && strings.HasPrefix(a.File, "custom-") {
+		return "public, max-age=31536000, immutable"
+	}
+	return "public, max-age=86400"
+}
+
+// ContentTypeFor fayl kengaytmasi bo'yicha Content-Type qaytaradi
+func ContentTypeFor(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".m3u8":
+		return "application/vnd.apple.mpegurl"
+	case ".ts":
+		return "video/mp2t"
+	case ".mpd":
+		return "application/dash+xml"
+	case ".m4s":
+		return "video/iso.segment"
+	case ".mp4":
+		return "video/mp4"
+	case ".m4a":
+		return "audio/mp4"
+	case ".opus":
+		return "audio/ogg"
+	case ".vtt":
+		return "text/vtt"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -4,13 +4,20 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 
+	"github.com/Coding-for-Machine/Videos-Service/assets"
 	"github.com/Coding-for-Machine/Videos-Service/config"
 	"github.com/Coding-for-Machine/Videos-Service/database"
 	"github.com/Coding-for-Machine/Videos-Service/handlers"
 	"github.com/Coding-for-Machine/Videos-Service/middleware"
+	"github.com/Coding-for-Machine/Videos-Service/queue"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
 	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+	"github.com/Coding-for-Machine/Videos-Service/transcoder"
 	"github.com/Coding-for-Machine/Videos-Service/workers"
+	"github.com/Coding-for-Machine/Videos-Service/workspace"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -20,38 +27,95 @@ import (
 
 func main() {
 	// Konfiguratsiya yuklash
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Konfiguratsiya xatosi: ", err)
+	}
+
+	// Sxema migratsiyalari: "migrate up|status"
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatal("Migratsiya xatosi: ", err)
+		}
+		return
+	}
 
 	// Cassandra ulanish
-	cassandraSession, err := database.NewCassandraDB(cfg.CassandraHosts)
+	if cfg.Cassandra.AutoMigrate {
+		if err := database.EnsureKeyspace(cfg.Cassandra); err != nil {
+			log.Fatal("Keyspace yaratilmadi:", err)
+		}
+	}
+	cassandraSession, err := database.NewCassandraDB(cfg.Cassandra)
 	if err != nil {
 		log.Fatal("Cassandra ulanmadi:", err)
 	}
 	defer cassandraSession.Close()
 
-	// MinIO ulanish
-	minioClient, err := database.NewMinIOClient(cfg.MinIO)
+	if err := prepareSchema(cassandraSession, cfg.Cassandra); err != nil {
+		log.Fatal("Cassandra sxemasi tayyor emas: ", err)
+	}
+
+	// Storage (MinIO yoki lokal disk)
+	store, err := storage.New(cfg)
 	if err != nil {
-		log.Fatal("MinIO ulanmadi:", err)
+		log.Fatal("Storage ulanmadi:", err)
 	}
+	buckets := cfg.Storage.Buckets
 
 	// Redis ulanish (queue uchun)
 	redisClient := database.NewRedisClient(cfg.RedisAddr)
 	defer redisClient.Close()
 
+	// Processing job navbati
+	jobQueues := queue.NewQueues(redisClient, "processing_queue", cfg.Queue.JobTypes(), cfg.Queue.VisibilityTimeout)
+	if cfg.Queue.WorkerID == "" {
+		cfg.Queue.WorkerID = queue.DefaultWorkerID()
+	}
+
+	// Turlar bo'yicha navbatlardan oldingi umumiy navbatdagi joblarni ko'chirish
+	legacyQueue := queue.New(redisClient, "processing_queue", cfg.Queue.VisibilityTimeout)
+	if n, err := workers.MigrateLegacyQueue(context.Background(), legacyQueue, jobQueues); err != nil {
+		log.Printf("Eski navbat ko'chirilmadi: %v", err)
+	} else if n > 0 {
+		log.Printf("Eski navbatdan ko'chirilgan joblar: %d", n)
+	}
+
+	// Processing ish papkalari
+	workspaces, err := workspace.NewManager(cfg.Processing.WorkDir, cfg.Processing.MinFreeSpace, cfg.Processing.WorkspaceRetention)
+	if err != nil {
+		log.Fatal("Workspace yaratilmadi:", err)
+	}
+
+	tc, err := transcoder.New(cfg.Processing.Transcoder)
+	if err != nil {
+		log.Fatal("Transcoder tanlanmadi:", err)
+	}
+
+	// Repositories
+	readConsistency, err := database.ParseConsistency(cfg.Cassandra.ReadConsistency)
+	if err != nil {
+		log.Fatal("Cassandra sozlamasi noto'g'ri:", err)
+	}
+	repos := repository.NewCassandraRepositories(cassandraSession, readConsistency)
+
 	// Services
-	videoService := services.NewVideoService(cassandraSession, minioClient, redisClient)
-	processingService := services.NewProcessingService(minioClient)
-	analyticsService := services.NewAnalyticsService(cassandraSession)
+	videoService := services.NewVideoService(repos, store, buckets, redisClient, jobQueues)
+	progressService := services.NewProgressService(redisClient)
+	processingService := services.NewProcessingService(tc, store, buckets, progressService, workspaces, cfg.Processing)
+	analyticsService := services.NewAnalyticsService(repos)
+	jobService := services.NewJobService(repos)
+	uploadService := services.NewUploadService(store, buckets, redisClient, videoService, cfg.Upload)
 
 	// Background workers ishga tushirish
 	ctx := context.Background()
 
-	// Video processing worker
-	go workers.VideoProcessingWorker(ctx, redisClient, processingService, videoService)
+	// Video processing pool (job turi bo'yicha cheklangan parallellik)
+	processingPool := workers.NewProcessingPool(jobQueues, cfg.Queue, processingService, videoService, jobService)
+	go processingPool.Run(ctx)
 
-	// Thumbnail generator worker
-	go workers.ThumbnailGeneratorWorker(ctx, redisClient, processingService, videoService)
+	// Ack qilinmagan va retry kutayotgan joblarni qaytarish
+	go workers.QueueReclaimWorker(ctx, processingPool)
 
 	// Analytics aggregator worker
 	go workers.AnalyticsAggregatorWorker(ctx, analyticsService)
@@ -59,9 +123,14 @@ func main() {
 	// View counter worker
 	go workers.ViewCounterWorker(ctx, redisClient, videoService)
 
+	// Resumable upload expiration worker
+	go workers.UploadExpirationWorker(ctx, uploadService)
+
 	// Fiber app
 	app := fiber.New(fiber.Config{
 		BodyLimit: 2 * 1024 * 1024 * 1024, // 2GB
+		// tus PATCH tanasini oqim sifatida o'qish uchun (boshqa handlerlar c.Body() bilan to'liq o'qiydi)
+		StreamRequestBody: true,
 	})
 
 	// Middleware
@@ -69,21 +138,65 @@ func main() {
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
-		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, Range, If-Range, " +
+			"Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata, Upload-Defer-Length",
+		AllowMethods: "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS",
+		ExposeHeaders: "Content-Range, Content-Length, Accept-Ranges, ETag, Last-Modified, " +
+			"Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Offset, Upload-Length, Upload-Expires",
 	}))
 
 	// Routes
 	api := app.Group("/api")
 
+	// Local storage presigned URLlari (MinIO o'rnida)
+	if local, ok := store.(*storage.LocalStore); ok {
+		objects := app.Group(storage.LocalObjectPrefix)
+		objects.Get("/:bucket/*", handlers.LocalPresignedGet(local))
+		objects.Put("/:bucket/*", handlers.LocalPresignedPut(local))
+	}
+
 	// Video routes
 	videos := api.Group("/videos")
 	videos.Post("/", middleware.RateLimit(), handlers.UploadVideo(videoService))
+	videos.Post("/uploads", middleware.RateLimit(), handlers.CreateUploadSession(uploadService))
+	videos.Post("/:id/complete", handlers.CompleteUpload(uploadService))
 	videos.Get("/", handlers.GetVideos(videoService))
 	videos.Get("/:id", handlers.GetVideo(videoService))
 	videos.Delete("/:id", handlers.DeleteVideo(videoService))
 	videos.Post("/:id/view", handlers.IncrementView(videoService))
-	videos.Get("/:id/stream", handlers.StreamVideo(videoService, minioClient))
+	videos.Get("/:id/stream", handlers.StreamVideo(videoService, store, buckets))
+	videos.Get("/:id/master.m3u8", handlers.HLSMasterPlaylist(videoService, store, buckets))
+	videos.Get("/:id/manifest.mpd", handlers.DASHManifest(videoService, store, buckets))
+	videos.Get("/:id/audio", handlers.GetVideoAudio(videoService))
+	videos.Get("/:id/thumbnails", handlers.GetThumbnails(videoService))
+	videos.Get("/:id/renditions/*", handlers.ServeAsset(videoService, store, buckets, assets.Renditions))
+	videos.Get("/:id/hls/*", handlers.ServeAsset(videoService, store, buckets, assets.HLS))
+	videos.Get("/:id/dash/*", handlers.ServeAsset(videoService, store, buckets, assets.DASH))
+	videos.Get("/:id/audio/*", handlers.ServeAsset(videoService, store, buckets, assets.Audio))
+	videos.Get("/:id/thumbnails/*", handlers.ServeAsset(videoService, store, buckets, assets.Thumbnails))
+	videos.Get("/:id/sprites/*", handlers.ServeAsset(videoService, store, buckets, assets.Sprites))
+	videos.Put("/:id/thumbnail", handlers.SetThumbnail(videoService))
+	videos.Get("/:id/jobs", handlers.GetVideoJobs(jobService))
+	videos.Get("/:id/progress", handlers.TranscodeProgress(progressService))
+
+	// Processing job routes
+	api.Get("/jobs/:job_id", handlers.GetJob(jobService))
+
+	// Metrics
+	api.Get("/metrics/queues", handlers.QueueMetrics(processingPool))
+
+	// Resumable upload routes (tus 1.0)
+	uploads := api.Group("/uploads", handlers.TusResumable())
+	uploads.Options("/", handlers.TusOptions(uploadService))
+	uploads.Post("/", middleware.RateLimit(), handlers.TusCreate(uploadService))
+	uploads.Options("/:id", handlers.TusOptions(uploadService))
+	uploads.Head("/:id", handlers.TusHead(uploadService))
+	uploads.Patch("/:id", handlers.TusPatch(uploadService))
+	uploads.Delete("/:id", handlers.TusDelete(uploadService))
+
+	// User (kanal) routes
+	users := api.Group("/users")
+	users.Get("/:user_id/videos", handlers.GetUserVideos(videoService))
 
 	// Analytics routes
 	analytics := api.Group("/analytics")
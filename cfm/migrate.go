@@ -0,0 +1,92 @@
+// migrate.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/Coding-for-Machine/Videos-Service/database"
+	"github.com/gocql/gocql"
+)
+
+const migrateUsage = "foydalanish: migrate up|status"
+
+// runMigrate "migrate up|status" buyrug'i
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) != 1 || (args[0] != "up" && args[0] != "status") {
+		return errors.New(migrateUsage)
+	}
+	ctx := context.Background()
+
+	if args[0] == "up" {
+		if err := database.EnsureKeyspace(cfg.Cassandra); err != nil {
+			return err
+		}
+	}
+
+	session, err := database.NewCassandraDB(cfg.Cassandra)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	migrator, err := database.NewMigrator(session, cfg.Cassandra.Keyspace, cfg.Cassandra.MigrationLockTTL)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "up" {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d ta migratsiya qo'llandi\n", applied)
+		return nil
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "-"
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, status.State(), appliedAt)
+	}
+	return w.Flush()
+}
+
+// prepareSchema server ishga tushishidan oldin sxemani tekshiradi: AutoMigrate
+// yoqilgan bo'lsa migratsiyalarni qo'llaydi, aks holda qo'llanmaganlari
+// qolmaganini talab qiladi
+func prepareSchema(session *gocql.Session, cfg config.CassandraConfig) error {
+	ctx := context.Background()
+
+	migrator, err := database.NewMigrator(session, cfg.Keyspace, cfg.MigrationLockTTL)
+	if err != nil {
+		return err
+	}
+
+	if cfg.AutoMigrate {
+		_, err := migrator.Up(ctx)
+		return err
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d ta migratsiya qo'llanmagan, avval \"migrate up\" ni ishga tushiring", pending)
+	}
+	return nil
+}
@@ -2,14 +2,60 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Port           string
-	CassandraHosts []string
-	MinIO          MinIOConfig
-	RedisAddr      string
+	Port       string
+	Cassandra  CassandraConfig
+	MinIO      MinIOConfig
+	RedisAddr  string
+	Processing ProcessingConfig
+	Upload     UploadConfig
+	Storage    StorageConfig
+	Queue      QueueConfig
+}
+
+type CassandraConfig struct {
+	Hosts    []string
+	Keyspace string
+	Username string // bo'sh bo'lsa autentifikatsiyasiz
+	Password string
+	LocalDC  string // token-aware + DC-aware routing uchun lokal data center (bo'sh - barcha DC)
+
+	ReplicationStrategy string         // "SimpleStrategy" yoki "NetworkTopologyStrategy"
+	ReplicationFactor   int            // SimpleStrategy uchun
+	DCReplication       map[string]int // NetworkTopologyStrategy uchun: DC -> replikalar soni
+
+	ReadConsistency   string // masalan "LOCAL_QUORUM"
+	WriteConsistency  string
+	SerialConsistency string // lightweight transactionlar uchun: "SERIAL" yoki "LOCAL_SERIAL"
+
+	ProtoVersion   int
+	ConnectTimeout time.Duration
+	Timeout        time.Duration
+
+	RetryAttempts   int           // bitta so'rov uchun qayta urinishlar soni
+	RetryMinBackoff time.Duration // eksponensial kutishning pastki va yuqori chegarasi
+	RetryMaxBackoff time.Duration
+
+	TLS CassandraTLSConfig
+
+	AutoMigrate      bool          // ishga tushishda migratsiyalarni qo'llash ("migrate up" o'rniga)
+	MigrationLockTTL time.Duration // migratsiya qulfi yangilanmasa shu muddatdan keyin bo'shaydi
+}
+
+type CassandraTLSConfig struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string // client sertifikati (mTLS) - ixtiyoriy
+	KeyFile    string
+	VerifyHost bool // sertifikatdagi nom host bilan mos kelishini tekshirish
 }
 
 type MinIOConfig struct {
@@ -20,26 +66,253 @@ type MinIOConfig struct {
 	BucketName      string
 }
 
-func Load() *Config {
+type StorageConfig struct {
+	Backend   string // "minio" yoki "local"
+	LocalPath string // local backend uchun papka
+	// Local backend presigned URLlari shu servis orqali beriladi (/storage/...):
+	// LocalPublicURL - klient ko'radigan manzil, LocalSigningKey - HMAC kaliti
+	// (bo'sh bo'lsa ishga tushganda tasodifiy kalit yaratiladi)
+	LocalPublicURL  string
+	LocalSigningKey string
+	Buckets         Buckets
+}
+
+// Buckets - obyektlar saqlanadigan bucket nomlari
+type Buckets struct {
+	Videos     string
+	Raw        string
+	Processed  string
+	Thumbnails string
+}
+
+func (b Buckets) All() []string {
+	return []string{b.Videos, b.Raw, b.Processed, b.Thumbnails}
+}
+
+type ProcessingConfig struct {
+	HLSEnabled     bool   // HLS (adaptive bitrate) paketlash
+	HLSSegmentTime int    // segment davomiyligi, soniya (HLS va DASH)
+	DASHEnabled    bool   // MPEG-DASH manifest va fMP4 segmentlar
+	WorkDir        string // videolar ish papkasi; har bir jarayon o'z ichki papkasini ishlatadi
+	MinFreeSpace   uint64 // ish paytida diskda qolishi kerak bo'lgan joy (bayt)
+
+	WorkspaceRetention time.Duration // oxirgi jobdan keyin manba fayl keshda turadigan vaqt
+	Transcoder         string        // "ffmpeg" yoki "fake" (ffmpeg o'rnatilmagan muhit uchun)
+
+	SpriteInterval  time.Duration // preview sprite kadrlari orasidagi vaqt
+	SpriteTileWidth int           // sprite ichidagi bitta kadr kengligi (piksel)
+}
+
+type UploadConfig struct {
+	MaxSize    int64         // bitta upload uchun maksimal hajm (bayt)
+	Expiration time.Duration // tugallanmagan upload qancha saqlanadi
+}
+
+type QueueConfig struct {
+	WorkerID          string        // bo'sh bo'lsa host nomi va PID ishlatiladi
+	VisibilityTimeout time.Duration // ack qilinmagan job shu muddatdan keyin qayta navbatga qaytadi
+	Retry             map[string]RetryPolicy
+	Concurrency       map[string]int // job turi bo'yicha bir vaqtda ishlanadigan joblar soni
+}
+
+// JobTypes sozlangan job turlari
+func (c QueueConfig) JobTypes() []string {
+	types := make([]string, 0, len(c.Concurrency))
+	for jobType := range c.Concurrency {
+		types = append(types, jobType)
+	}
+	return types
+}
+
+// RetryPolicy - job turi uchun qayta urinish sozlamalari
+type RetryPolicy struct {
+	MaxAttempts int           // jami urinishlar soni (birinchisi ham kiradi)
+	BaseDelay   time.Duration // birinchi qayta urinishdan oldingi kutish
+	MaxDelay    time.Duration // eksponensial kutishning yuqori chegarasi
+}
+
+// RetryPolicyFor job turi uchun siyosat (sozlanmagan turlar uchun default)
+func (c QueueConfig) RetryPolicyFor(jobType string) RetryPolicy {
+	if policy, ok := c.Retry[jobType]; ok {
+		return policy
+	}
+	return RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: 5 * time.Minute}
+}
+
+// Load muhit o'zgaruvchilaridan konfiguratsiyani o'qiydi. Noto'g'ri yozilgan
+// replikatsiya sozlamasi xato - jim tashlab yuborilsa keyspace boshqa
+// replikatsiya bilan yaratilib qolardi.
+func Load() (*Config, error) {
+	bucketName := getEnv("MINIO_BUCKET", "videos")
+	port := getEnv("PORT", "3000")
+	dcReplication, err := getEnvIntMap("CASSANDRA_DC_REPLICATION") // masalan "dc1:3,dc2:3"
+	if err != nil {
+		return nil, err
+	}
+	// Qo'shtirnoqsiz CQL nomlari kichik harfga o'tadi; system_schema
+	// so'rovlari ham shu nom bilan ishlashi uchun oldindan kichik harf
+	keyspace := strings.ToLower(getEnv("CASSANDRA_KEYSPACE", "youtube_clone"))
+
 	return &Config{
-		Port: getEnv("PORT", "3000"),
-		CassandraHosts: []string{
-			getEnv("CASSANDRA_HOST", "127.0.0.1:9042"),
+		Port: port,
+		Cassandra: CassandraConfig{
+			Hosts:    getEnvList("CASSANDRA_HOSTS", []string{getEnv("CASSANDRA_HOST", "127.0.0.1:9042")}),
+			Keyspace: keyspace,
+			Username: getEnv("CASSANDRA_USERNAME", ""),
+			Password: getEnv("CASSANDRA_PASSWORD", ""),
+			LocalDC:  getEnv("CASSANDRA_LOCAL_DC", ""),
+
+			ReplicationStrategy: getEnv("CASSANDRA_REPLICATION_STRATEGY", defaultReplicationStrategy(dcReplication)),
+			ReplicationFactor:   getEnvInt("CASSANDRA_REPLICATION_FACTOR", 1),
+			DCReplication:       dcReplication,
+
+			ReadConsistency:   getEnv("CASSANDRA_READ_CONSISTENCY", "QUORUM"),
+			WriteConsistency:  getEnv("CASSANDRA_WRITE_CONSISTENCY", "QUORUM"),
+			SerialConsistency: getEnv("CASSANDRA_SERIAL_CONSISTENCY", "SERIAL"),
+
+			ProtoVersion:   getEnvInt("CASSANDRA_PROTO_VERSION", 4),
+			ConnectTimeout: time.Duration(getEnvInt("CASSANDRA_CONNECT_TIMEOUT_SEC", 10)) * time.Second,
+			Timeout:        time.Duration(getEnvInt("CASSANDRA_TIMEOUT_SEC", 10)) * time.Second,
+
+			RetryAttempts:   getEnvInt("CASSANDRA_RETRY_ATTEMPTS", 3),
+			RetryMinBackoff: time.Duration(getEnvInt("CASSANDRA_RETRY_MIN_BACKOFF_MS", 100)) * time.Millisecond,
+			RetryMaxBackoff: time.Duration(getEnvInt("CASSANDRA_RETRY_MAX_BACKOFF_MS", 2000)) * time.Millisecond,
+
+			TLS: CassandraTLSConfig{
+				Enabled:    getEnvBool("CASSANDRA_TLS_ENABLED", false),
+				CAFile:     getEnv("CASSANDRA_TLS_CA_FILE", ""),
+				CertFile:   getEnv("CASSANDRA_TLS_CERT_FILE", ""),
+				KeyFile:    getEnv("CASSANDRA_TLS_KEY_FILE", ""),
+				VerifyHost: getEnvBool("CASSANDRA_TLS_VERIFY_HOST", true),
+			},
+
+			AutoMigrate:      getEnvBool("CASSANDRA_AUTO_MIGRATE", true),
+			MigrationLockTTL: time.Duration(getEnvInt("CASSANDRA_MIGRATION_LOCK_TTL_SEC", 60)) * time.Second,
 		},
 		MinIO: MinIOConfig{
 			Endpoint:        getEnv("MINIO_ENDPOINT", "localhost:9000"),
 			AccessKeyID:     getEnv("MINIO_ACCESS_KEY", "minioadmin"),
 			SecretAccessKey: getEnv("MINIO_SECRET_KEY", "minioadmin"),
 			UseSSL:          false,
-			BucketName:      getEnv("MINIO_BUCKET", "videos"),
+			BucketName:      bucketName,
 		},
 		RedisAddr: getEnv("REDIS_ADDR", "localhost:6379"),
+		Processing: ProcessingConfig{
+			HLSEnabled:         getEnvBool("HLS_ENABLED", true),
+			HLSSegmentTime:     getEnvInt("HLS_SEGMENT_TIME", 4),
+			DASHEnabled:        getEnvBool("DASH_ENABLED", true),
+			WorkDir:            getEnv("WORKSPACE_DIR", filepath.Join(os.TempDir(), "videos-service")),
+			MinFreeSpace:       uint64(getEnvInt("WORKSPACE_MIN_FREE_MB", 1024)) * 1024 * 1024,
+			WorkspaceRetention: time.Duration(getEnvInt("WORKSPACE_RETENTION_SEC", 600)) * time.Second,
+			Transcoder:         getEnv("TRANSCODER", "ffmpeg"),
+			SpriteInterval:     time.Duration(getEnvInt("SPRITE_INTERVAL_SEC", 10)) * time.Second,
+			SpriteTileWidth:    getEnvInt("SPRITE_TILE_WIDTH", 160),
+		},
+		Storage: StorageConfig{
+			Backend:         getEnv("STORAGE_BACKEND", "minio"),
+			LocalPath:       getEnv("STORAGE_LOCAL_PATH", "./data"),
+			LocalPublicURL:  getEnv("STORAGE_LOCAL_PUBLIC_URL", "http://localhost:"+port),
+			LocalSigningKey: getEnv("STORAGE_LOCAL_SIGNING_KEY", ""),
+			Buckets: Buckets{
+				Videos:     bucketName,
+				Raw:        getEnv("STORAGE_RAW_BUCKET", bucketName+"-raw"),
+				Processed:  getEnv("STORAGE_PROCESSED_BUCKET", bucketName+"-processed"),
+				Thumbnails: getEnv("STORAGE_THUMBNAILS_BUCKET", "thumbnails"),
+			},
+		},
+		Upload: UploadConfig{
+			MaxSize:    int64(getEnvInt("UPLOAD_MAX_SIZE_MB", 10*1024)) * 1024 * 1024,
+			Expiration: time.Duration(getEnvInt("UPLOAD_EXPIRATION_HOURS", 24)) * time.Hour,
+		},
+		Queue: QueueConfig{
+			WorkerID:          getEnv("WORKER_ID", ""),
+			VisibilityTimeout: time.Duration(getEnvInt("QUEUE_VISIBILITY_TIMEOUT_SEC", 300)) * time.Second,
+			Retry: map[string]RetryPolicy{
+				"transcode":     getRetryPolicy("TRANSCODE", 3, 30, 600),
+				"thumbnail":     getRetryPolicy("THUMBNAIL", 5, 10, 300),
+				"probe":         getRetryPolicy("PROBE", 3, 10, 120),
+				"extract_audio": getRetryPolicy("EXTRACT_AUDIO", 3, 30, 600),
+				"sprites":       getRetryPolicy("SPRITES", 3, 30, 600),
+			},
+			Concurrency: map[string]int{
+				"transcode":     getEnvInt("TRANSCODE_CONCURRENCY", 1),
+				"thumbnail":     getEnvInt("THUMBNAIL_CONCURRENCY", 4),
+				"probe":         getEnvInt("PROBE_CONCURRENCY", 4),
+				"extract_audio": getEnvInt("EXTRACT_AUDIO_CONCURRENCY", 2),
+				"sprites":       getEnvInt("SPRITES_CONCURRENCY", 2),
+			},
+		},
+	}, nil
+}
+
+// getRetryPolicy <PREFIX>_MAX_ATTEMPTS, <PREFIX>_RETRY_BASE_SEC va
+// <PREFIX>_RETRY_MAX_SEC o'zgaruvchilaridan siyosat yig'adi
+func getRetryPolicy(prefix string, attempts, baseSec, maxSec int) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: getEnvInt(prefix+"_MAX_ATTEMPTS", attempts),
+		BaseDelay:   time.Duration(getEnvInt(prefix+"_RETRY_BASE_SEC", baseSec)) * time.Second,
+		MaxDelay:    time.Duration(getEnvInt(prefix+"_RETRY_MAX_SEC", maxSec)) * time.Second,
 	}
 }
 
+// defaultReplicationStrategy DC lar bo'yicha replikatsiya berilgan bo'lsa
+// NetworkTopologyStrategy, aks holda SimpleStrategy
+func defaultReplicationStrategy(dcReplication map[string]int) string {
+	if len(dcReplication) > 0 {
+		return "NetworkTopologyStrategy"
+	}
+	return "SimpleStrategy"
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList vergul bilan ajratilgan ro'yxat ("a,b,c")
+func getEnvList(key string, defaultValue []string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// getEnvIntMap "kalit:son" juftliklari ro'yxati ("dc1:3,dc2:2"); noto'g'ri juftlik xato
+func getEnvIntMap(key string) (map[string]int, error) {
+	values := make(map[string]int)
+	for _, pair := range getEnvList(key, nil) {
+		name, value, ok := strings.Cut(pair, ":")
+		name = strings.TrimSpace(name)
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || name == "" || err != nil {
+			return nil, fmt.Errorf("%s: noto'g'ri juftlik %q (kutilgan nom:son)", key, pair)
+		}
+		if _, dup := values[name]; dup {
+			return nil, fmt.Errorf("%s: %q ikki marta berilgan", key, name)
+		}
+		values[name] = n
+	}
+	return values, nil
+}
@@ -0,0 +1,49 @@
+// database/backfill.go
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// backfillVideosByUser videos_by_user jadvalini videos dan to'ldiradi.
+// Kanal ro'yxati qo'shilishidan oldin yuklangan videolarning u yerda qatori
+// yo'q. INSERT upsert bo'lgani uchun mavjud qatorlar joriy sarlavha va
+// thumbnail bilan qayta yoziladi - qayta ishga tushirish xavfsiz.
+func backfillVideosByUser(ctx context.Context, session *gocql.Session, keyspace string) error {
+	iter := session.Query("SELECT id, user_id, created_at, title, thumbnail_url, status FROM videos").
+		WithContext(ctx).PageSize(500).Iter()
+
+	var (
+		id, userID                  gocql.UUID
+		createdAt                   time.Time
+		title, thumbnailURL, status string
+	)
+
+	copied, skipped := 0, 0
+	for iter.Scan(&id, &userID, &createdAt, &title, &thumbnailURL, &status) {
+		// Clustering kalitining qismi bo'sh bo'lsa qatorni yozib bo'lmaydi;
+		// fayli yuklanmagan videolar kanalga MarkUploaded da qo'shiladi
+		if userID == (gocql.UUID{}) || createdAt.IsZero() || status == "uploading" {
+			skipped++
+			continue
+		}
+
+		err := session.Query(`INSERT INTO videos_by_user (user_id, created_at, video_id, title, thumbnail_url)
+			VALUES (?, ?, ?, ?, ?)`, userID, createdAt, id, title, thumbnailURL).WithContext(ctx).Exec()
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		copied++
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("videos_by_user ga %d ta video yozildi (%d ta o'tkazib yuborildi)", copied, skipped)
+	return nil
+}
@@ -2,162 +2,205 @@
 package database
 
 import (
+	"fmt"
 	"log"
-	"time"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/Coding-for-Machine/Videos-Service/config"
 	"github.com/gocql/gocql"
 )
 
-// NewCassandraDB Cassandra bilan ulanishni yaratadi,
-// agar keyspace mavjud bo‘lmasa — uni yaratadi va table’larni tayyorlaydi.
-func NewCassandraDB(hosts []string) (*gocql.Session, error) {
-	// 1️⃣ Avval default cluster (keyspace’siz) orqali ulanamiz
-	cluster := gocql.NewCluster(hosts...)
-	cluster.Consistency = gocql.Quorum
-	cluster.ProtoVersion = 4
-	cluster.ConnectTimeout = 10 * time.Second
-	cluster.Timeout = 10 * time.Second
+// Keyspace va DC nomlari CQL matniga qo'yiladi (bind qilib bo'lmaydi), shuning uchun tekshiriladi.
+// Keyspace qo'shtirnoqsiz yoziladi va Cassandra uni kichik harfga o'tkazadi -
+// system_schema so'rovlari bilan mos kelishi uchun faqat kichik harf qabul qilinadi.
+var (
+	keyspacePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,47}$`)
+	dcNamePattern   = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+)
 
-	session, err := cluster.CreateSession()
+// newCluster konfiguratsiya bo'yicha cluster sozlamalari (keyspace'siz).
+// Default consistency - yozish darajasi; o'qishlar repositoryda alohida beriladi.
+func newCluster(cfg config.CassandraConfig) (*gocql.ClusterConfig, error) {
+	writeConsistency, err := ParseConsistency(cfg.WriteConsistency)
 	if err != nil {
 		return nil, err
 	}
-	defer session.Close()
+	var serialConsistency gocql.SerialConsistency
+	if err := serialConsistency.UnmarshalText([]byte(strings.ToUpper(cfg.SerialConsistency))); err != nil {
+		return nil, fmt.Errorf("noto'g'ri serial consistency %q", cfg.SerialConsistency)
+	}
 
-	// 2️⃣ Keyspace yaratish (agar mavjud bo‘lmasa)
-	if err := createKeyspace(session); err != nil {
-		return nil, err
+	cluster := gocql.NewCluster(cfg.Hosts...)
+	cluster.Consistency = writeConsistency
+	cluster.SerialConsistency = serialConsistency
+	cluster.ProtoVersion = cfg.ProtoVersion
+	cluster.ConnectTimeout = cfg.ConnectTimeout
+	cluster.Timeout = cfg.Timeout
+
+	// Token-aware: so'rov to'g'ridan-to'g'ri replikaga boradi; LocalDC berilsa
+	// faqat shu DC dagi nodelar ishlatiladi (boshqa DC - faqat zaxira)
+	if cfg.LocalDC != "" {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy(cfg.LocalDC))
+	} else {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
+	}
+
+	cluster.RetryPolicy = &gocql.ExponentialBackoffRetryPolicy{
+		NumRetries: cfg.RetryAttempts,
+		Min:        cfg.RetryMinBackoff,
+		Max:        cfg.RetryMaxBackoff,
+	}
+
+	if cfg.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: cfg.Username,
+			Password: cfg.Password,
+		}
+	}
+
+	if cfg.TLS.Enabled {
+		cluster.SslOpts = &gocql.SslOptions{
+			CaPath:                 cfg.TLS.CAFile,
+			CertPath:               cfg.TLS.CertFile,
+			KeyPath:                cfg.TLS.KeyFile,
+			EnableHostVerification: cfg.TLS.VerifyHost,
+		}
 	}
-	log.Println("✅ Keyspace 'youtube_clone' mavjud yoki yaratildi")
 
-	// 3️⃣ Endi yangi cluster — youtube_clone keyspace bilan
-	cluster.Keyspace = "youtube_clone"
-	keyspaceSession, err := cluster.CreateSession()
+	return cluster, nil
+}
+
+// ParseConsistency "QUORUM", "local_quorum" kabi nomni gocql darajasiga aylantiradi
+func ParseConsistency(name string) (gocql.Consistency, error) {
+	consistency, err := gocql.ParseConsistencyWrapper(strings.ToUpper(name))
+	if err != nil {
+		return 0, fmt.Errorf("noto'g'ri consistency %q", name)
+	}
+	return consistency, nil
+}
+
+// NewCassandraDB sozlangan keyspace'ga ulanadi. Jadvallar bu yerda
+// yaratilmaydi - sxema migratsiyalar orqali boshqariladi (migrate.go).
+func NewCassandraDB(cfg config.CassandraConfig) (*gocql.Session, error) {
+	cluster, err := newCluster(cfg)
 	if err != nil {
 		return nil, err
 	}
+	cluster.Keyspace = cfg.Keyspace
 
-	// 4️⃣ Table’larni yaratish
-	if err := createTables(keyspaceSession); err != nil {
+	session, err := cluster.CreateSession()
+	if err != nil {
 		return nil, err
 	}
-	log.Println("✅ Cassandra table’lar yaratildi")
 
 	log.Println("🚀 Cassandra ulanish muvaffaqiyatli o‘rnatildi")
-	return keyspaceSession, nil
+	return session, nil
 }
 
-// createKeyspace — youtube_clone keyspace’ni yaratadi
-func createKeyspace(session *gocql.Session) error {
-	query := `
-	CREATE KEYSPACE IF NOT EXISTS youtube_clone
-	WITH replication = {
-		'class': 'SimpleStrategy',
-		'replication_factor': 1
-	}`
-	return session.Query(query).Exec()
+// EnsureKeyspace keyspace’ni yaratadi (agar mavjud bo‘lmasa). Keyspace’siz
+// sessiya orqali ishlaydi, shuning uchun migratsiyalardan oldin chaqiriladi.
+// Mavjud keyspace replikatsiyasi o‘zgartirilmaydi (ALTER dan keyin repair kerak) -
+// farq bo‘lsa faqat ogohlantiriladi.
+func EnsureKeyspace(cfg config.CassandraConfig) error {
+	replication, err := replicationMap(cfg)
+	if err != nil {
+		return err
+	}
+
+	cluster, err := newCluster(cfg)
+	if err != nil {
+		return err
+	}
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	query := fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH replication = %s",
+		cfg.Keyspace, formatReplication(replication))
+	if err := session.Query(query).Exec(); err != nil {
+		return err
+	}
+
+	var current map[string]string
+	err = session.Query("SELECT replication FROM system_schema.keyspaces WHERE keyspace_name = ?",
+		cfg.Keyspace).Scan(&current)
+	if err == nil && !sameReplication(current, replication) {
+		log.Printf("⚠️ Keyspace '%s' replikatsiyasi sozlamadan farq qiladi: %v (kutilgan %v)",
+			cfg.Keyspace, current, replication)
+	}
+
+	log.Printf("✅ Keyspace '%s' mavjud yoki yaratildi", cfg.Keyspace)
+	return nil
 }
 
-// createTables — barcha zarur jadval (table)larni yaratadi
-func createTables(session *gocql.Session) error {
-	tables := []string{
-		// Videos table
-		`CREATE TABLE IF NOT EXISTS videos (
-			id UUID PRIMARY KEY,
-			title TEXT,
-			description TEXT,
-			user_id UUID,
-			username TEXT,
-			file_name TEXT,
-			file_size BIGINT,
-			duration INT,
-			thumbnail_url TEXT,
-			video_url TEXT,
-			status TEXT,
-			quality_versions MAP<TEXT, TEXT>,
-			views COUNTER,
-			likes COUNTER,
-			dislikes COUNTER,
-			created_at TIMESTAMP,
-			updated_at TIMESTAMP
-		)`,
-
-		// Videos by user
-		`CREATE TABLE IF NOT EXISTS videos_by_user (
-			user_id UUID,
-			created_at TIMESTAMP,
-			video_id UUID,
-			title TEXT,
-			thumbnail_url TEXT,
-			views COUNTER,
-			PRIMARY KEY (user_id, created_at, video_id)
-		) WITH CLUSTERING ORDER BY (created_at DESC)`,
-
-		// Trending videos
-		`CREATE TABLE IF NOT EXISTS trending_videos (
-			time_bucket TEXT,
-			views COUNTER,
-			video_id UUID,
-			title TEXT,
-			thumbnail_url TEXT,
-			created_at TIMESTAMP,
-			PRIMARY KEY (time_bucket, views, video_id)
-		) WITH CLUSTERING ORDER BY (views DESC)`,
-
-		// Video analytics
-		`CREATE TABLE IF NOT EXISTS video_analytics (
-			video_id UUID,
-			date DATE,
-			hour INT,
-			views COUNTER,
-			watch_time COUNTER,
-			likes COUNTER,
-			shares COUNTER,
-			PRIMARY KEY ((video_id, date), hour)
-		)`,
-
-		// Search index (simplified)
-		`CREATE TABLE IF NOT EXISTS video_search (
-			keyword TEXT,
-			video_id UUID,
-			title TEXT,
-			thumbnail_url TEXT,
-			views COUNTER,
-			created_at TIMESTAMP,
-			PRIMARY KEY (keyword, views, video_id)
-		) WITH CLUSTERING ORDER BY (views DESC)`,
-
-		// Comments
-		`CREATE TABLE IF NOT EXISTS comments (
-			video_id UUID,
-			created_at TIMESTAMP,
-			comment_id UUID,
-			user_id UUID,
-			username TEXT,
-			text TEXT,
-			likes COUNTER,
-			PRIMARY KEY (video_id, created_at, comment_id)
-		) WITH CLUSTERING ORDER BY (created_at DESC)`,
-
-		// Processing queue
-		`CREATE TABLE IF NOT EXISTS processing_jobs (
-			job_id UUID PRIMARY KEY,
-			video_id UUID,
-			job_type TEXT,
-			status TEXT,
-			priority INT,
-			retry_count INT,
-			error_message TEXT,
-			created_at TIMESTAMP,
-			updated_at TIMESTAMP
-		)`,
-	}
-
-	for _, query := range tables {
-		if err := session.Query(query).Exec(); err != nil {
-			return err
+// replicationMap konfiguratsiyadan keyspace replikatsiya parametrlari
+func replicationMap(cfg config.CassandraConfig) (map[string]string, error) {
+	if !keyspacePattern.MatchString(cfg.Keyspace) {
+		return nil, fmt.Errorf("noto'g'ri keyspace nomi %q", cfg.Keyspace)
+	}
+
+	switch cfg.ReplicationStrategy {
+	case "SimpleStrategy":
+		if cfg.ReplicationFactor < 1 {
+			return nil, fmt.Errorf("replication factor kamida 1 bo'lishi kerak")
+		}
+		return map[string]string{
+			"class":              "SimpleStrategy",
+			"replication_factor": strconv.Itoa(cfg.ReplicationFactor),
+		}, nil
+
+	case "NetworkTopologyStrategy":
+		if len(cfg.DCReplication) == 0 {
+			return nil, fmt.Errorf("NetworkTopologyStrategy uchun CASSANDRA_DC_REPLICATION kerak (masalan dc1:3,dc2:3)")
 		}
+		replication := map[string]string{"class": "NetworkTopologyStrategy"}
+		for dc, factor := range cfg.DCReplication {
+			if !dcNamePattern.MatchString(dc) || factor < 1 {
+				return nil, fmt.Errorf("noto'g'ri DC replikatsiyasi %s:%d", dc, factor)
+			}
+			replication[dc] = strconv.Itoa(factor)
+		}
+		return replication, nil
+
+	default:
+		return nil, fmt.Errorf("noma'lum replikatsiya strategiyasi %q", cfg.ReplicationStrategy)
 	}
+}
 
-	return nil
+// formatReplication CQL map literali: {'class': '...', 'dc1': '3'}
+func formatReplication(replication map[string]string) string {
+	keys := make([]string, 0, len(replication))
+	for key := range replication {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("'%s': '%s'", key, replication[key])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// sameReplication system_schema dagi replikatsiya sozlama bilan mosligini
+// tekshiradi (class to'liq nom bilan saqlanadi: org.apache.cassandra.locator.*)
+func sameReplication(current, expected map[string]string) bool {
+	if len(current) != len(expected) {
+		return false
+	}
+	for key, value := range expected {
+		got := current[key]
+		if key == "class" {
+			got = got[strings.LastIndex(got, ".")+1:]
+		}
+		if got != value {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,251 @@
+// database/legacy.go
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Eski sxemada views/likes/dislikes COUNTER ustunlari oddiy ustunlar bilan bir
+// jadvalda (videos, videos_by_user, comments) yoki primary keyda (trending_videos,
+// video_search) edi. Cassandra bunday jadvalni yaratmaydi, lekin jadvallari
+// qo'lda (masalan BIGINT bilan) yaratilgan klasterlar bo'lishi mumkin.
+// migrateLegacySchema ularni yangi sxemaga keltiradi. Qayta ishga tushirish
+// xavfsiz: ko'chirilgan ustunlar o'chiriladi, hisoblagich qiymatlari esa faqat
+// hali yozuvi yo'q qatorlarga qo'shiladi.
+func migrateLegacySchema(ctx context.Context, session *gocql.Session, keyspace string) error {
+	steps := []struct {
+		name string
+		fn   func(context.Context, *gocql.Session, string) error
+	}{
+		{"videos", migrateVideoCounters},
+		{"videos_by_user", migrateVideosByUser},
+		{"comments", migrateCommentLikes},
+		{"trending_videos", migrateTrending},
+		{"video_search", migrateSearch},
+	}
+
+	for _, step := range steps {
+		if err := step.fn(ctx, session, keyspace); err != nil {
+			return fmt.Errorf("%s migratsiyasi: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+// tableColumns jadval ustunlari va ularning CQL turi (jadval yo'q bo'lsa bo'sh)
+func tableColumns(ctx context.Context, session *gocql.Session, keyspace, table string) (map[string]string, error) {
+	query := "SELECT column_name, type FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ?"
+	iter := session.Query(query, keyspace, table).WithContext(ctx).Iter()
+
+	columns := make(map[string]string)
+	var name, typ string
+	for iter.Scan(&name, &typ) {
+		columns[name] = typ
+	}
+	return columns, iter.Close()
+}
+
+// presentColumns names ichidan jadvalda mavjudlarini qaytaradi
+func presentColumns(columns map[string]string, names ...string) []string {
+	var present []string
+	for _, name := range names {
+		if _, ok := columns[name]; ok {
+			present = append(present, name)
+		}
+	}
+	return present
+}
+
+// migrateVideoCounters videos dagi hisoblagichlarni video_counters ga ko'chiradi
+// va eski ustunlarni o'chiradi
+func migrateVideoCounters(ctx context.Context, session *gocql.Session, keyspace string) error {
+	columns, err := tableColumns(ctx, session, keyspace, "videos")
+	if err != nil {
+		return err
+	}
+	legacy := presentColumns(columns, "views", "likes", "dislikes")
+	if len(legacy) == 0 {
+		return nil
+	}
+	log.Printf("videos jadvalida eski hisoblagichlar topildi: %v", legacy)
+
+	iter := session.Query(fmt.Sprintf("SELECT id, %s FROM videos", strings.Join(legacy, ", "))).WithContext(ctx).Iter()
+
+	var id gocql.UUID
+	values := make([]int64, len(legacy))
+	dest := []interface{}{&id}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+
+	copied := 0
+	for iter.Scan(dest...) {
+		ok, err := copyCounters(ctx, session, "video_counters", "video_id = ?", []interface{}{id}, legacy, values)
+		if err != nil {
+			iter.Close()
+			return err
+		}
+		if ok {
+			copied++
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("video_counters ga %d ta video ko'chirildi", copied)
+	return session.Query(fmt.Sprintf("ALTER TABLE videos DROP (%s)", strings.Join(legacy, ", "))).WithContext(ctx).Exec()
+}
+
+// migrateCommentLikes comments.likes ni comment_counters ga ko'chiradi
+func migrateCommentLikes(ctx context.Context, session *gocql.Session, keyspace string) error {
+	columns, err := tableColumns(ctx, session, keyspace, "comments")
+	if err != nil {
+		return err
+	}
+	if _, ok := columns["likes"]; !ok {
+		return nil
+	}
+
+	iter := session.Query("SELECT video_id, comment_id, likes FROM comments").WithContext(ctx).Iter()
+
+	var videoID, commentID gocql.UUID
+	values := make([]int64, 1)
+	for iter.Scan(&videoID, &commentID, &values[0]) {
+		_, err := copyCounters(ctx, session, "comment_counters", "video_id = ? AND comment_id = ?",
+			[]interface{}{videoID, commentID}, []string{"likes"}, values)
+		if err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	return session.Query("ALTER TABLE comments DROP likes").WithContext(ctx).Exec()
+}
+
+// copyCounters counter jadvalida qator hali yo'q bo'lsa qiymatlarni qo'shadi.
+// Counter UPDATE idempotent emas, shuning uchun mavjud qator qayta ko'chirilmaydi.
+func copyCounters(ctx context.Context, session *gocql.Session, table, where string, key []interface{}, columns []string, values []int64) (bool, error) {
+	var exists int
+	err := session.Query(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, where), key...).WithContext(ctx).Scan(&exists)
+	if err != nil {
+		return false, err
+	}
+
+	var sets []string
+	var args []interface{}
+	for i, column := range columns {
+		if values[i] != 0 {
+			sets = append(sets, fmt.Sprintf("%s = %s + ?", column, column))
+			args = append(args, values[i])
+		}
+	}
+	if exists > 0 || len(sets) == 0 {
+		return false, nil
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), where)
+	return true, session.Query(query, append(args, key...)...).WithContext(ctx).Exec()
+}
+
+// migrateVideosByUser denormalizatsiya qilingan views ustunini o'chiradi
+// (sonlar endi video_counters dan o'qiladi)
+func migrateVideosByUser(ctx context.Context, session *gocql.Session, keyspace string) error {
+	columns, err := tableColumns(ctx, session, keyspace, "videos_by_user")
+	if err != nil {
+		return err
+	}
+	if _, ok := columns["views"]; !ok {
+		return nil
+	}
+	return session.Query("ALTER TABLE videos_by_user DROP views").WithContext(ctx).Exec()
+}
+
+// migrateTrending eski trending_videos ni o'chiradi; keyingi aggregation
+// (soatlik) uni qayta to'ldiradi
+func migrateTrending(ctx context.Context, session *gocql.Session, keyspace string) error {
+	columns, err := tableColumns(ctx, session, keyspace, "trending_videos")
+	if err != nil {
+		return err
+	}
+	if typ, ok := columns["views"]; !ok || typ == "bigint" {
+		return nil
+	}
+
+	if err := session.Query("DROP TABLE trending_videos").WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+	return recreateTable(ctx, session, "trending_videos")
+}
+
+// recreateTable jadvalni boshlang'ich migratsiyadagi (0001) ta'rifi bo'yicha yaratadi
+func recreateTable(ctx context.Context, session *gocql.Session, table string) error {
+	data, err := migrationFiles.ReadFile("migrations/0001_initial_schema.cql")
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range splitStatements(string(data)) {
+		if strings.HasPrefix(stmt, "CREATE TABLE IF NOT EXISTS "+table+" (") {
+			return session.Query(stmt).WithContext(ctx).Exec()
+		}
+	}
+	return fmt.Errorf("%s jadvali ta'rifi topilmadi", table)
+}
+
+// migrateSearch video_search ni yangi primary key bilan qayta yaratadi va
+// indeksni ko'chiradi (primary keyni ALTER bilan o'zgartirib bo'lmaydi)
+func migrateSearch(ctx context.Context, session *gocql.Session, keyspace string) error {
+	columns, err := tableColumns(ctx, session, keyspace, "video_search")
+	if err != nil {
+		return err
+	}
+	if _, ok := columns["views"]; !ok {
+		return nil
+	}
+
+	type entry struct {
+		keyword      string
+		videoID      gocql.UUID
+		title        string
+		thumbnailURL string
+		createdAt    time.Time
+	}
+
+	var entries []entry
+	iter := session.Query("SELECT keyword, video_id, title, thumbnail_url, created_at FROM video_search").WithContext(ctx).Iter()
+	var e entry
+	for iter.Scan(&e.keyword, &e.videoID, &e.title, &e.thumbnailURL, &e.createdAt) {
+		entries = append(entries, e)
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	if err := session.Query("DROP TABLE video_search").WithContext(ctx).Exec(); err != nil {
+		return err
+	}
+	if err := recreateTable(ctx, session, "video_search"); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO video_search (keyword, video_id, title, thumbnail_url, created_at)
+		VALUES (?, ?, ?, ?, ?)`
+	for _, e := range entries {
+		if err := session.Query(query, e.keyword, e.videoID, e.title, e.thumbnailURL, e.createdAt).WithContext(ctx).Exec(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("video_search qayta yaratildi (%d ta yozuv)", len(entries))
+	return nil
+}
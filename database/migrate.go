@@ -0,0 +1,426 @@
+// database/migrate.go
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+//go:embed migrations/*.cql
+var migrationFiles embed.FS
+
+// Migratsiya fayli nomi: 0001_initial_schema.cql
+var migrationFilePattern = regexp.MustCompile(`^([0-9]+)_([a-z0-9_]+)\.cql$`)
+
+// Migration - sxemaning bitta versiyasi. Odatda migrations/ dagi CQL fayl;
+// CQL bilan ifodalab bo'lmaydigan ma'lumot ko'chirishlar Go funksiya sifatida
+// goMigrations da ro'yxatga olinadi.
+type Migration struct {
+	Version    int
+	Name       string
+	Checksum   string
+	Statements []string
+
+	run func(ctx context.Context, session *gocql.Session, keyspace string) error
+}
+
+// steps migratsiyadagi qadamlar soni (Go migratsiya - bitta qadam)
+func (m Migration) steps() int {
+	if m.run != nil {
+		return 1
+	}
+	return len(m.Statements)
+}
+
+// goMigrations - Go da yozilgan migratsiyalar (Checksum qo'lda beriladi).
+// Qo'llangan migratsiya qayta ishga tushirilmaydi - o'zgarish uchun yangi versiya qo'shing.
+var goMigrations = []Migration{
+	{Version: 2, Name: "split_legacy_counters", Checksum: "go:split_legacy_counters", run: migrateLegacySchema},
+	{Version: 3, Name: "add_video_columns", Checksum: "go:add_video_columns", run: addVideoColumns},
+	{Version: 4, Name: "backfill_videos_by_user", Checksum: "go:backfill_videos_by_user", run: backfillVideosByUser},
+}
+
+// LoadMigrations embed qilingan CQL fayllar va Go migratsiyalarni versiya
+// tartibida qaytaradi
+func LoadMigrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := append([]Migration(nil), goMigrations...)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("noto'g'ri migratsiya fayli nomi: %s", entry.Name())
+		}
+
+		data, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		sum := sha256.Sum256(data)
+		migrations = append(migrations, Migration{
+			Version:    version,
+			Name:       match[2],
+			Checksum:   hex.EncodeToString(sum[:]),
+			Statements: splitStatements(string(data)),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migratsiya versiyasi takrorlangan: %d", migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// splitStatements CQL matnni ";" bo'yicha buyruqlarga ajratadi.
+// Izohlar (--, //, /* */) olib tashlanadi, qo'shtirnoq ichidagi ";" hisobga olinmaydi.
+func splitStatements(cql string) []string {
+	var statements []string
+	var current strings.Builder
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(cql); i++ {
+		c := cql[i]
+		switch {
+		case c == '\'':
+			// String literal ('' - escape qilingan qo'shtirnoq)
+			end := i + 1
+			for end < len(cql) {
+				if cql[end] == '\'' {
+					if end+1 < len(cql) && cql[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			current.WriteString(cql[i:min(end+1, len(cql))])
+			i = end
+		case strings.HasPrefix(cql[i:], "--"), strings.HasPrefix(cql[i:], "//"):
+			for i < len(cql) && cql[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case strings.HasPrefix(cql[i:], "/*"):
+			end := strings.Index(cql[i+2:], "*/")
+			if end < 0 {
+				i = len(cql)
+			} else {
+				i += end + 3
+			}
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// MigrationStatus - migratsiyaning klasterdagi holati
+type MigrationStatus struct {
+	Migration
+	Applied   bool      // to'liq qo'llangan
+	Progress  int       // qo'llangan qadamlar (yarmida to'xtagan bo'lsa)
+	AppliedAt time.Time // oxirgi qadam vaqti
+	Changed   bool      // qo'llangandan keyin fayl o'zgartirilgan
+}
+
+// State holatning qisqa nomi (CLI uchun)
+func (s MigrationStatus) State() string {
+	switch {
+	case s.Changed:
+		return "changed"
+	case s.Applied:
+		return "applied"
+	case s.Progress > 0:
+		return fmt.Sprintf("partial %d/%d", s.Progress, s.steps())
+	default:
+		return "pending"
+	}
+}
+
+// Migrator migratsiyalarni qo'llaydi. Bir nechta replika bir vaqtda ishga
+// tushsa ham faqat bittasi qo'llaydi: schema_migrations_lock dagi qator
+// lightweight transaction (IF NOT EXISTS) bilan egallanadi va TTL bilan
+// yangilanib turadi - jarayon o'lib qolsa qulf o'zi bo'shaydi.
+type Migrator struct {
+	session    *gocql.Session
+	keyspace   string
+	migrations []Migration
+	owner      string
+	lockTTL    time.Duration
+}
+
+const migrationLockName = "schema"
+
+func NewMigrator(session *gocql.Session, keyspace string, lockTTL time.Duration) (*Migrator, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if lockTTL < 10*time.Second {
+		lockTTL = 10 * time.Second
+	}
+
+	return &Migrator{
+		session:    session,
+		keyspace:   keyspace,
+		migrations: migrations,
+		owner:      gocql.TimeUUID().String(),
+		lockTTL:    lockTTL,
+	}, nil
+}
+
+// ensureTables migratsiya jadvallarini yaratadi
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name TEXT,
+			checksum TEXT,
+			statements_applied INT,
+			completed BOOLEAN,
+			applied_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			name TEXT PRIMARY KEY,
+			owner TEXT,
+			acquired_at TIMESTAMP
+		)`,
+	}
+
+	for _, query := range queries {
+		if err := m.session.Query(query).WithContext(ctx).Exec(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status har bir migratsiyaning holati (versiya tartibida)
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var exists int
+	err := m.session.Query("SELECT COUNT(*) FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?",
+		m.keyspace, "schema_migrations").WithContext(ctx).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]MigrationStatus)
+	if exists > 0 {
+		iter := m.session.Query(`SELECT version, checksum, statements_applied, completed, applied_at
+			FROM schema_migrations`).WithContext(ctx).Iter()
+
+		var version int
+		var status MigrationStatus
+		for iter.Scan(&version, &status.Checksum, &status.Progress, &status.Applied, &status.AppliedAt) {
+			applied[version] = status
+			status = MigrationStatus{}
+		}
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = row.Applied
+			status.Progress = row.Progress
+			status.AppliedAt = row.AppliedAt
+			status.Changed = row.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending qo'llanmagan (yoki yarmida qolgan) migratsiyalar soni
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Up barcha qo'llanmagan migratsiyalarni qulf ostida qo'llaydi va qo'llanganlar
+// sonini qaytaradi
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return 0, fmt.Errorf("migratsiya jadvallari yaratilmadi: %w", err)
+	}
+
+	ctx, release, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	// Holat qulf olingandan keyin o'qiladi - boshqa replika qo'llagan bo'lishi mumkin
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, status := range statuses {
+		if status.Changed {
+			return 0, fmt.Errorf("migratsiya %04d_%s qo'llangandan keyin o'zgartirilgan; yangi migratsiya qo'shing",
+				status.Version, status.Name)
+		}
+	}
+
+	applied := 0
+	for _, status := range statuses {
+		if status.Applied {
+			continue
+		}
+		if err := m.apply(ctx, status); err != nil {
+			return applied, fmt.Errorf("migratsiya %04d_%s: %w", status.Version, status.Name, err)
+		}
+		applied++
+		log.Printf("Migratsiya qo'llandi: %04d_%s", status.Version, status.Name)
+	}
+
+	return applied, nil
+}
+
+// apply migratsiyani status.Progress dan boshlab qo'llaydi. Har bir qadamdan
+// keyin holat yoziladi, xato bo'lsa keyingi urinish shu joydan davom etadi.
+func (m *Migrator) apply(ctx context.Context, status MigrationStatus) error {
+	migration := status.Migration
+	total := migration.steps()
+
+	for step := status.Progress; step < total; step++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var err error
+		if migration.run != nil {
+			err = migration.run(ctx, m.session, m.keyspace)
+		} else {
+			err = m.session.Query(migration.Statements[step]).WithContext(ctx).Exec()
+		}
+		if err != nil {
+			return fmt.Errorf("qadam %d/%d: %w", step+1, total, err)
+		}
+
+		err = m.session.Query(`INSERT INTO schema_migrations (version, name, checksum,
+			statements_applied, completed, applied_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			migration.Version, migration.Name, migration.Checksum, step+1, step+1 == total, time.Now()).
+			WithContext(ctx).Exec()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lock migratsiya qulfini egallaydi (band bo'lsa kutadi). Qaytgan context qulf
+// yo'qotilsa bekor qilinadi; release qulfni bo'shatadi.
+func (m *Migrator) lock(ctx context.Context) (context.Context, func(), error) {
+	ttl := int(m.lockTTL.Seconds())
+	waiting := false
+
+	for {
+		existing := make(map[string]interface{})
+		acquired, err := m.session.Query(`INSERT INTO schema_migrations_lock (name, owner, acquired_at)
+			VALUES (?, ?, ?) IF NOT EXISTS USING TTL ?`,
+			migrationLockName, m.owner, time.Now(), ttl).WithContext(ctx).MapScanCAS(existing)
+		if err != nil {
+			return nil, nil, fmt.Errorf("migratsiya qulfi olinmadi: %w", err)
+		}
+		if acquired {
+			break
+		}
+
+		if !waiting {
+			log.Printf("Migratsiya qulfi band (%v), kutilmoqda...", existing["owner"])
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	// Heartbeat: qulf TTL tugashidan oldin yangilanadi
+	go func() {
+		ticker := time.NewTicker(m.lockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				applied, err := m.session.Query(`UPDATE schema_migrations_lock USING TTL ?
+					SET owner = ?, acquired_at = ? WHERE name = ? IF owner = ?`,
+					ttl, m.owner, time.Now(), migrationLockName, m.owner).MapScanCAS(make(map[string]interface{}))
+				if err != nil || !applied {
+					log.Printf("Migratsiya qulfi yo'qotildi: %v", err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	release := func() {
+		close(done)
+		cancel()
+
+		_, err := m.session.Query("DELETE FROM schema_migrations_lock WHERE name = ? IF owner = ?",
+			migrationLockName, m.owner).MapScanCAS(make(map[string]interface{}))
+		if err != nil {
+			log.Printf("Migratsiya qulfi bo'shatilmadi (TTL dan keyin bo'shaydi): %v", err)
+		}
+	}
+
+	return lockCtx, release, nil
+}
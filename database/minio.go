@@ -10,7 +10,7 @@ import (
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
-func NewMinIOClient(cfg config.MinIOConfig) (*minio.Client, error) {
+func NewMinIOClient(cfg config.MinIOConfig, buckets []string) (*minio.Client, error) {
 	client, err := minio.New(cfg.Endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
 		Secure: cfg.UseSSL,
@@ -21,13 +21,6 @@ func NewMinIOClient(cfg config.MinIOConfig) (*minio.Client, error) {
 
 	// Bucket yaratish
 	ctx := context.Background()
-	buckets := []string{
-		cfg.BucketName,                // videos
-		cfg.BucketName + "-raw",       // raw videos
-		cfg.BucketName + "-processed", // processed videos
-		"thumbnails",                  // thumbnails
-	}
-
 	for _, bucketName := range buckets {
 		exists, err := client.BucketExists(ctx, bucketName)
 		if err != nil {
@@ -0,0 +1,70 @@
+// database/video_columns.go
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gocql/gocql"
+)
+
+// videoColumns - boshlang'ich sxemadan keyin videos jadvaliga qo'shilgan
+// ustunlar. 0001 dagi CREATE TABLE IF NOT EXISTS mavjud jadvalga ta'sir
+// qilmaydi, shuning uchun eski klasterlarda ular ALTER bilan qo'shiladi.
+var videoColumns = []struct {
+	name, typ string
+}{
+	{"width", "int"},
+	{"height", "int"},
+	{"fps", "double"},
+	{"video_codec", "text"},
+	{"audio_codec", "text"},
+	{"bitrate", "bigint"},
+	{"container", "text"},
+	{"thumbnail_candidates", "text"},
+	{"preview_track_url", "text"},
+	{"error_message", "text"},
+	{"quality_versions", "map<text, text>"},
+	{"audio_versions", "map<text, text>"},
+	{"media_info", "text"},
+}
+
+// addVideoColumns videos jadvalida yo'q ustunlarni qo'shadi (system_schema.columns
+// bo'yicha tekshiriladi). Ustun boshqa tur bilan mavjud bo'lsa xato - uni
+// qo'lda ko'chirish kerak. Qayta ishga tushirish xavfsiz.
+func addVideoColumns(ctx context.Context, session *gocql.Session, keyspace string) error {
+	columns, err := tableColumns(ctx, session, keyspace, "videos")
+	if err != nil {
+		return err
+	}
+
+	var added []string
+	for _, column := range videoColumns {
+		typ, ok := columns[column.name]
+		if ok {
+			if normalizeType(typ) != column.typ {
+				return fmt.Errorf("videos.%s turi %s, kutilgan %s", column.name, typ, column.typ)
+			}
+			continue
+		}
+
+		query := fmt.Sprintf("ALTER TABLE videos ADD %s %s", column.name, column.typ)
+		if err := session.Query(query).WithContext(ctx).Exec(); err != nil {
+			return fmt.Errorf("videos.%s qo'shilmadi: %w", column.name, err)
+		}
+		added = append(added, column.name)
+	}
+
+	if len(added) > 0 {
+		log.Printf("videos jadvaliga ustunlar qo'shildi: %v", added)
+	}
+	return nil
+}
+
+// normalizeType system_schema dagi tur yozuvini solishtirish uchun
+// ("map<text,text>" va "map<text, text>" bir xil)
+func normalizeType(typ string) string {
+	return strings.ReplaceAll(strings.ToLower(typ), ",", ", ")
+}
@@ -0,0 +1,93 @@
+// handlers/asset_handlers.go
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ServeAsset "/:id/<tur>/*" marshrutidagi faylni beradi. Storage kaliti va
+// bucket assets paketidan olinadi - URL yaratgan qoida bilan bir xil.
+func ServeAsset(videoService *services.VideoService, store storage.ObjectStore, buckets config.Buckets, kind assets.Kind) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		video, err := videoService.GetVideo(c.Context(), c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Video topilmadi",
+			})
+		}
+
+		asset, err := assets.Parse(video.ID, kind, c.Params("*"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Noto'g'ri fayl nomi",
+			})
+		}
+
+		return serveAsset(c, store, buckets, asset)
+	}
+}
+
+// serveAsset kesh headerlari bilan assetni yuboradi
+func serveAsset(c *fiber.Ctx, store storage.ObjectStore, buckets config.Buckets, asset assets.Asset) error {
+	c.Set("Cache-Control", asset.CacheControl())
+	return serveObject(c, store, asset.Bucket(buckets), asset.Key(), asset.ContentType())
+}
+
+// HLSMasterPlaylist master playlistni video manzili ostida beradi
+// (undagi variantlar "hls/<sifat>/index.m3u8" nisbiy yo'l bilan yozilgan)
+func HLSMasterPlaylist(videoService *services.VideoService, store storage.ObjectStore, buckets config.Buckets) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		video, err := videoService.GetVideo(c.Context(), c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Video topilmadi",
+			})
+		}
+
+		return serveAsset(c, store, buckets, assets.Of(video.ID, assets.HLS, "master.m3u8"))
+	}
+}
+
+// DASHManifest manifestga segmentlar shu API orqali yuklanishi uchun BaseURL qo'shib beradi
+func DASHManifest(videoService *services.VideoService, store storage.ObjectStore, buckets config.Buckets) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		video, err := videoService.GetVideo(c.Context(), c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Video topilmadi",
+			})
+		}
+
+		asset := assets.Of(video.ID, assets.DASH, "manifest.mpd")
+		object, err := store.Get(c.Context(), asset.Bucket(buckets), asset.Key())
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Manifest topilmadi",
+			})
+		}
+		defer object.Close()
+
+		manifest, err := io.ReadAll(object)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Manifest topilmadi",
+			})
+		}
+
+		baseURL := strings.TrimSuffix(asset.URL(), "manifest.mpd")
+		manifest = bytes.Replace(manifest, []byte("<Period"),
+			[]byte("<BaseURL>"+baseURL+"</BaseURL>\n\t<Period"), 1)
+
+		c.Set("Content-Type", asset.ContentType())
+		c.Set("Cache-Control", asset.CacheControl())
+		return c.Send(manifest)
+	}
+}
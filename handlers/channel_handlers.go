@@ -0,0 +1,39 @@
+// handlers/channel_handlers.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetUserVideos kanal sahifasi: foydalanuvchi videolari yangilari birinchi.
+// Keyingi sahifa javobdagi next_cursor ni ?cursor= ga berib olinadi.
+func GetUserVideos(videoService *services.VideoService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit, err := pageLimit(c, 20)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		videos, next, err := videoService.ListUserVideos(c.Context(), c.Params("user_id"), c.Query("cursor"), limit)
+		if err != nil {
+			status := listErrorStatus(err)
+			if errors.Is(err, services.ErrInvalidUserID) {
+				status = 400
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"videos":      videos,
+			"total":       len(videos),
+			"next_cursor": next,
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// handlers/job_handlers.go
+package handlers
+
+import (
+	"errors"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
+	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetVideoJobs videoning barcha processing joblari (frontend progress uchun)
+func GetVideoJobs(jobService *services.JobService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		videoID := c.Params("id")
+
+		jobs, err := jobService.GetVideoJobs(c.Context(), videoID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if jobs == nil {
+			jobs = []models.ProcessingJob{}
+		}
+
+		return c.JSON(fiber.Map{
+			"video_id": videoID,
+			"jobs":     jobs,
+		})
+	}
+}
+
+func GetJob(jobService *services.JobService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		jobID := c.Params("job_id")
+
+		job, err := jobService.GetJob(c.Context(), jobID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Job topilmadi",
+			})
+		}
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(job)
+	}
+}
+
+// QueueMetrics job turi bo'yicha navbat uzunligi va ishlanayotgan joblar
+func QueueMetrics(pool services.PoolMetricsProvider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		metrics, err := pool.Metrics(c.Context())
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"queues": metrics,
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// handlers/local_storage_handlers.go
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocalPresignedGet local backend presigned yuklab olish URLini beradi
+// (MinIO presigned GET o'rnida; Range so'rovlari ham ishlaydi)
+func LocalPresignedGet(store *storage.LocalStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bucket, key, params, err := presignedRequest(c)
+		if err == nil {
+			err = store.VerifyPresigned("GET", bucket, key, params)
+		}
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return serveObject(c, store, bucket, key, "application/octet-stream")
+	}
+}
+
+// LocalPresignedPut presigned URL orqali multipart partni qabul qiladi.
+// S3 kabi javobda ETag qaytariladi - klient uni complete so'rovida yuboradi.
+func LocalPresignedPut(store *storage.LocalStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bucket, key, params, err := presignedRequest(c)
+		if err == nil {
+			err = store.VerifyPresigned("PUT", bucket, key, params)
+		}
+		if err != nil {
+			return c.Status(403).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		partNumber, err := strconv.Atoi(params.Get("partNumber"))
+		if err != nil || partNumber < 1 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "partNumber noto'g'ri",
+			})
+		}
+
+		part, err := store.UploadPart(c.Context(), bucket, key, params.Get("uploadId"), partNumber,
+			c.Context().RequestBodyStream(), int64(c.Request().Header.ContentLength()))
+		if err != nil {
+			status := 500
+			if errors.Is(err, storage.ErrNotFound) {
+				status = 404
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		c.Set("ETag", `"`+part.ETag+`"`)
+		return c.SendStatus(200)
+	}
+}
+
+// presignedRequest "/storage/:bucket/*" so'rovidan bucket, key va query parametrlarini oladi
+func presignedRequest(c *fiber.Ctx) (string, string, url.Values, error) {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return "", "", nil, storage.ErrInvalidSignature
+	}
+
+	params, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return "", "", nil, storage.ErrInvalidSignature
+	}
+
+	return c.Params("bucket"), key, params, nil
+}
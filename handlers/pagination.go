@@ -0,0 +1,33 @@
+// handlers/pagination.go
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxPageSize ro'yxat endpointlarida bitta sahifadagi eng ko'p element soni
+const maxPageSize = 100
+
+// pageLimit ?limit= ni o'qiydi va tekshiradi. Keyingi sahifa javobdagi
+// next_cursor ni ?cursor= ga berib olinadi. Javobdagi "total" avvalgidek
+// shu sahifadagi elementlar soni (umumiy son emas - Cassandra da uni
+// hisoblash butun partition/jadvalni o'qishni talab qiladi).
+func pageLimit(c *fiber.Ctx, defaultLimit int) (int, error) {
+	limit := c.QueryInt("limit", defaultLimit)
+	if limit < 1 || limit > maxPageSize {
+		return 0, fmt.Errorf("limit 1 va %d oralig'ida bo'lishi kerak", maxPageSize)
+	}
+	return limit, nil
+}
+
+// listErrorStatus sahifalangan ro'yxat xatosi uchun HTTP status
+func listErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidCursor) {
+		return 400
+	}
+	return 500
+}
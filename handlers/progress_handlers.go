@@ -0,0 +1,93 @@
+// handlers/progress_handlers.go
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/gocql/gocql"
+	"github.com/gofiber/fiber/v2"
+)
+
+// TranscodeProgress transcoding progressini Server-Sent Events orqali yuboradi.
+// Ulanishda oxirgi holat darhol yuboriladi, keyin har bir yangilanish;
+// "done" bosqichidan keyin oqim yopiladi.
+func TranscodeProgress(progressService *services.ProgressService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		videoID, err := gocql.ParseUUID(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Noto'g'ri video ID",
+			})
+		}
+
+		// Body writer handler qaytgandan keyin ishlaydi - c.Context() ishlatib bo'lmaydi
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// Avval obuna bo'lib, keyin oxirgi holatni o'qiymiz - orada yangilanish yo'qolmaydi
+		sub := progressService.Subscribe(ctx, videoID)
+		if _, err := sub.Receive(ctx); err != nil {
+			sub.Close()
+			cancel()
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		latest, _ := progressService.Latest(ctx, videoID)
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no") // nginx buferlamasligi uchun
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer cancel()
+			defer sub.Close()
+
+			if latest != nil {
+				data, _ := json.Marshal(latest)
+				if writeSSE(w, "progress", data) != nil || latest.Done() {
+					return
+				}
+			}
+
+			messages := sub.Channel()
+			heartbeat := time.NewTicker(15 * time.Second)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case msg, ok := <-messages:
+					if !ok {
+						return
+					}
+					if writeSSE(w, "progress", []byte(msg.Payload)) != nil {
+						return // klient uzildi
+					}
+
+					var p services.Progress
+					if json.Unmarshal([]byte(msg.Payload), &p) == nil && p.Done() {
+						return
+					}
+				case <-heartbeat.C:
+					// Proxylar ulanishni yopib qo'ymasligi uchun
+					fmt.Fprint(w, ": ping\n\n")
+					if w.Flush() != nil {
+						return
+					}
+				}
+			}
+		})
+
+		return nil
+	}
+}
+
+func writeSSE(w *bufio.Writer, event string, data []byte) error {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return w.Flush()
+}
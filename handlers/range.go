@@ -0,0 +1,309 @@
+// handlers/range.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Bitta so'rovda ruxsat etilgan eng ko'p range soni
+const maxRanges = 16
+
+var errInvalidRange = errors.New("noto'g'ri range")
+
+// byteRange - [start, start+length) oralig'i
+type byteRange struct {
+	start  int64
+	length int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// parseRange "Range: bytes=..." headerini RFC 7233 bo'yicha parse qiladi.
+// Header bo'sh bo'lsa nil qaytaradi (to'liq fayl yuboriladi).
+// Hech bir range faylga to'g'ri kelmasa errInvalidRange qaytaradi (416).
+func parseRange(header string, size int64) ([]byteRange, error) {
+	if header == "" {
+		return nil, nil
+	}
+
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, errInvalidRange
+	}
+
+	var ranges []byteRange
+
+	for _, spec := range strings.Split(header[len(prefix):], ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		startStr, endStr, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, errInvalidRange
+		}
+		startStr = strings.TrimSpace(startStr)
+		endStr = strings.TrimSpace(endStr)
+
+		var r byteRange
+		if startStr == "" {
+			// Suffix range: oxirgi N bayt
+			n, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errInvalidRange
+			}
+			if n > size {
+				n = size
+			}
+			if n == 0 {
+				continue
+			}
+			r.start = size - n
+			r.length = n
+		} else {
+			start, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil || start < 0 {
+				return nil, errInvalidRange
+			}
+			if start >= size {
+				continue
+			}
+			r.start = start
+
+			if endStr == "" {
+				r.length = size - start
+			} else {
+				end, err := strconv.ParseInt(endStr, 10, 64)
+				if err != nil || end < start {
+					return nil, errInvalidRange
+				}
+				if end >= size {
+					end = size - 1
+				}
+				r.length = end - start + 1
+			}
+		}
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 || len(ranges) > maxRanges {
+		return nil, errInvalidRange
+	}
+
+	return ranges, nil
+}
+
+// ifRangeMatches If-Range headerini ETag yoki Last-Modified bilan solishtiradi.
+// Mos kelmasa range e'tiborsiz qoldiriladi va to'liq fayl yuboriladi.
+func ifRangeMatches(header, etag string, lastModified time.Time) bool {
+	if header == "" {
+		return true
+	}
+
+	// ETag (faqat strong taqqoslash)
+	if strings.HasPrefix(header, `"`) {
+		return etag != "" && header == etag
+	}
+	if strings.HasPrefix(header, "W/") {
+		return false
+	}
+
+	// HTTP-date
+	t, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+	return lastModified.Truncate(time.Second).Equal(t)
+}
+
+// notModified If-None-Match (ustuvor) yoki If-Modified-Since bo'yicha
+// klientdagi nusxa hali yangi ekanini tekshiradi (304)
+func notModified(ifNoneMatch, ifModifiedSince, etag string, lastModified time.Time) bool {
+	if ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || (etag != "" && candidate == strings.TrimPrefix(etag, "W/")) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince == "" {
+		return false
+	}
+	t, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(t)
+}
+
+// quoteETag storage ETagini HTTP formatiga keltiradi
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// countingWriter yozilgan baytlar sonini hisoblaydi
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+func (r byteRange) mimeHeader(contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Range": {r.contentRange(size)},
+		"Content-Type":  {contentType},
+	}
+}
+
+// rangesSize multipart/byteranges javobining to'liq uzunligini hisoblaydi
+func rangesSize(ranges []byteRange, boundary, contentType string, size int64) int64 {
+	var w countingWriter
+	mw := multipart.NewWriter(&w)
+	mw.SetBoundary(boundary)
+	for _, r := range ranges {
+		mw.CreatePart(r.mimeHeader(contentType, size))
+		w += countingWriter(r.length)
+	}
+	mw.Close()
+	return int64(w)
+}
+
+// serveObject storage obyektini Range, If-Range, ETag va shartli GET (304)
+// qo'llab-quvvatlagan holda yuboradi
+func serveObject(c *fiber.Ctx, store storage.ObjectStore, bucket, objectName, defaultContentType string) error {
+	ctx := c.Context()
+
+	info, err := store.Stat(ctx, bucket, objectName)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "Fayl topilmadi",
+		})
+	}
+
+	contentType := info.ContentType
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = defaultContentType
+	}
+	etag := quoteETag(info.ETag)
+
+	c.Set("Accept-Ranges", "bytes")
+	if etag != "" {
+		c.Set("ETag", etag)
+	}
+	c.Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+
+	if notModified(c.Get("If-None-Match"), c.Get("If-Modified-Since"), etag, info.LastModified) {
+		return c.SendStatus(304)
+	}
+
+	var ranges []byteRange
+	if ifRangeMatches(c.Get("If-Range"), etag, info.LastModified) {
+		ranges, err = parseRange(c.Get("Range"), info.Size)
+		if err != nil {
+			c.Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+			return c.Status(416).JSON(fiber.Map{
+				"error": "Range noto'g'ri",
+			})
+		}
+	}
+
+	switch len(ranges) {
+	case 0:
+		c.Set("Content-Type", contentType)
+		c.Status(200)
+		if c.Method() == fiber.MethodHead {
+			c.Response().Header.SetContentLength(int(info.Size))
+			return nil
+		}
+
+		object, err := store.Get(ctx, bucket, objectName)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		c.Context().SetBodyStream(object, int(info.Size))
+		return nil
+
+	case 1:
+		r := ranges[0]
+		c.Set("Content-Type", contentType)
+		c.Set("Content-Range", r.contentRange(info.Size))
+		c.Status(206)
+		if c.Method() == fiber.MethodHead {
+			c.Response().Header.SetContentLength(int(r.length))
+			return nil
+		}
+
+		object, err := store.GetRange(ctx, bucket, objectName, r.start, r.length)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		c.Context().SetBodyStream(object, int(r.length))
+		return nil
+	}
+
+	// Bir nechta range - multipart/byteranges
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	sendSize := rangesSize(ranges, mw.Boundary(), contentType, info.Size)
+
+	c.Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	c.Status(206)
+	if c.Method() == fiber.MethodHead {
+		pw.Close()
+		c.Response().Header.SetContentLength(int(sendSize))
+		return nil
+	}
+
+	go func() {
+		for _, r := range ranges {
+			part, err := mw.CreatePart(r.mimeHeader(contentType, info.Size))
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			object, err := store.GetRange(context.Background(), bucket, objectName, r.start, r.length)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			_, err = io.CopyN(part, object, r.length)
+			object.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		mw.Close()
+		pw.Close()
+	}()
+
+	c.Context().SetBodyStream(pr, int(sendSize))
+	return nil
+}
@@ -0,0 +1,309 @@
+// handlers/upload_handlers.go
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+// tus 1.0 protokoli: https://tus.io/protocols/resumable-upload
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination,expiration"
+)
+
+// TusResumable barcha tus javoblariga Tus-Resumable headerini qo'shadi
+// va klient versiyasini tekshiradi
+func TusResumable() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Tus-Resumable", tusVersion)
+
+		if c.Method() != fiber.MethodOptions && c.Get("Tus-Resumable") != tusVersion {
+			c.Set("Tus-Version", tusVersion)
+			return c.Status(412).JSON(fiber.Map{
+				"error": "Tus-Resumable versiyasi qo'llab-quvvatlanmaydi",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+func TusOptions(uploadService *services.UploadService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Tus-Version", tusVersion)
+		c.Set("Tus-Extension", tusExtensions)
+		c.Set("Tus-Max-Size", strconv.FormatInt(uploadService.MaxSize(), 10))
+		return c.SendStatus(204)
+	}
+}
+
+func TusCreate(uploadService *services.UploadService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("Upload-Defer-Length") != "" {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Upload-Defer-Length qo'llab-quvvatlanmaydi",
+			})
+		}
+
+		length, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+		if err != nil || length <= 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Upload-Length kerak",
+			})
+		}
+		if length > uploadService.MaxSize() {
+			return c.Status(413).JSON(fiber.Map{
+				"error": "Fayl juda katta",
+			})
+		}
+
+		meta, err := parseUploadMetadata(c.Get("Upload-Metadata"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Upload-Metadata noto'g'ri",
+			})
+		}
+		if meta.Title == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Title kerak",
+			})
+		}
+		if !allowedVideoTypes[meta.FileType] {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Faqat video fayllar ruxsat etilgan",
+			})
+		}
+
+		upload, err := uploadService.CreateUpload(c.Context(), length, meta)
+		if err != nil {
+			return tusError(c, err)
+		}
+
+		c.Set("Location", c.BaseURL()+strings.TrimSuffix(c.Path(), "/")+"/"+upload.ID.String())
+		c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		return c.SendStatus(201)
+	}
+}
+
+func TusHead(uploadService *services.UploadService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set("Cache-Control", "no-store")
+
+		upload, err := uploadService.GetUpload(c.Context(), c.Params("id"))
+		if err != nil {
+			return tusError(c, err)
+		}
+
+		c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		c.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+		c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		return c.SendStatus(200)
+	}
+}
+
+// TusPatch bo'lakni yozadi. Oxirgi bo'lakdan keyin video yaratiladi;
+// video ID upload ID bilan bir xil.
+func TusPatch(uploadService *services.UploadService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("Content-Type") != "application/offset+octet-stream" {
+			return c.Status(415).JSON(fiber.Map{
+				"error": "Content-Type application/offset+octet-stream bo'lishi kerak",
+			})
+		}
+
+		offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+		if err != nil || offset < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Upload-Offset kerak",
+			})
+		}
+
+		// Tana oqim sifatida o'qiladi (StreamRequestBody) - bo'lak xotiraga to'liq yuklanmaydi
+		length := int64(c.Request().Header.ContentLength())
+		if length < 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Content-Length kerak",
+			})
+		}
+
+		upload, video, err := uploadService.WriteChunk(c.Context(), c.Params("id"), offset, c.Context().RequestBodyStream(), length)
+		if err != nil {
+			return tusError(c, err)
+		}
+
+		c.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		if video == nil {
+			c.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		}
+		return c.SendStatus(204)
+	}
+}
+
+func TusDelete(uploadService *services.UploadService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := uploadService.TerminateUpload(c.Context(), c.Params("id")); err != nil {
+			return tusError(c, err)
+		}
+		return c.SendStatus(204)
+	}
+}
+
+func tusError(c *fiber.Ctx, err error) error {
+	status := 500
+	switch {
+	case errors.Is(err, services.ErrUploadNotFound):
+		status = 404
+	case errors.Is(err, services.ErrOffsetMismatch):
+		status = 409
+	case errors.Is(err, services.ErrUploadLocked):
+		status = 423
+	case errors.Is(err, services.ErrUploadTooLarge):
+		status = 413
+	case errors.Is(err, services.ErrInvalidUserID):
+		status = 400
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// parseUploadMetadata "key base64value,key2 base64value2" formatini parse qiladi
+func parseUploadMetadata(header string) (models.UploadMetadata, error) {
+	values := make(map[string]string)
+
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return models.UploadMetadata{}, fmt.Errorf("%s: %w", key, err)
+		}
+		values[key] = string(value)
+	}
+
+	meta := models.UploadMetadata{
+		Title:       values["title"],
+		Description: values["description"],
+		UserID:      values["user_id"],
+		Username:    values["username"],
+		FileName:    path.Base(values["filename"]),
+		FileType:    values["filetype"],
+	}
+	if meta.Username == "" {
+		meta.Username = "Anonymous"
+	}
+	if meta.FileName == "." || meta.FileName == "/" {
+		meta.FileName = "video.mp4"
+	}
+
+	return meta, nil
+}
+
+// CreateUploadSession presigned (direct-to-MinIO) upload sessiyasini ochadi
+func CreateUploadSession(uploadService *services.UploadService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req models.UploadSessionRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Noto'g'ri so'rov",
+			})
+		}
+
+		if req.Title == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Title kerak",
+			})
+		}
+		if req.FileSize <= 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "file_size kerak",
+			})
+		}
+		if !allowedVideoTypes[req.FileType] {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Faqat video fayllar ruxsat etilgan",
+			})
+		}
+		req.FileName = path.Base(req.FileName)
+		if req.FileName == "." || req.FileName == "/" {
+			req.FileName = "video.mp4"
+		}
+		if req.Username == "" {
+			req.Username = "Anonymous"
+		}
+
+		video, session, err := uploadService.CreateUploadSession(c.Context(), req)
+		if err != nil {
+			if errors.Is(err, services.ErrUploadTooLarge) {
+				return c.Status(413).JSON(fiber.Map{
+					"error": "Fayl juda katta",
+				})
+			}
+			if errors.Is(err, services.ErrInvalidUserID) {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "user_id (UUID) kerak",
+				})
+			}
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(201).JSON(fiber.Map{
+			"video":      video,
+			"part_size":  session.PartSize,
+			"parts":      session.Parts,
+			"expires_at": session.ExpiresAt,
+		})
+	}
+}
+
+// CompleteUpload presigned uploadni yakunlaydi va processingni boshlaydi
+func CompleteUpload(uploadService *services.UploadService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req struct {
+			Parts []models.UploadedPart `json:"parts"`
+		}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "Noto'g'ri so'rov",
+				})
+			}
+		}
+
+		video, err := uploadService.CompleteUploadSession(c.Context(), c.Params("id"), req.Parts)
+		if err != nil {
+			status := 500
+			switch {
+			case errors.Is(err, services.ErrSessionNotFound):
+				status = 404
+			case errors.Is(err, services.ErrUploadIncomplete):
+				status = 400
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "Video yuklandi va processing boshlandi",
+			"video":   video,
+		})
+	}
+}
@@ -2,14 +2,25 @@
 package handlers
 
 import (
-	"io"
+	"errors"
 
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/Coding-for-Machine/Videos-Service/config"
 	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
 	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
 	"github.com/gofiber/fiber/v2"
-	"github.com/minio/minio-go/v7"
 )
 
+// Ruxsat etilgan video turlari
+var allowedVideoTypes = map[string]bool{
+	"video/mp4":       true,
+	"video/webm":      true,
+	"video/quicktime": true,
+	"video/x-msvideo": true,
+}
+
 func UploadVideo(videoService *services.VideoService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Form ma'lumotlarini olish
@@ -17,6 +28,7 @@ func UploadVideo(videoService *services.VideoService) fiber.Handler {
 		if err := c.BodyParser(&req); err != nil {
 			req.Title = c.FormValue("title")
 			req.Description = c.FormValue("description")
+			req.UserID = c.FormValue("user_id")
 			req.Username = c.FormValue("username", "Anonymous")
 		}
 
@@ -35,9 +47,7 @@ func UploadVideo(videoService *services.VideoService) fiber.Handler {
 		}
 
 		// Fayl turini tekshirish
-		contentType := file.Header.Get("Content-Type")
-		if contentType != "video/mp4" && contentType != "video/webm" &&
-			contentType != "video/quicktime" && contentType != "video/x-msvideo" {
+		if !allowedVideoTypes[file.Header.Get("Content-Type")] {
 			return c.Status(400).JSON(fiber.Map{
 				"error": "Faqat video fayllar ruxsat etilgan",
 			})
@@ -57,6 +67,7 @@ func UploadVideo(videoService *services.VideoService) fiber.Handler {
 			c.Context(),
 			req.Title,
 			req.Description,
+			req.UserID,
 			req.Username,
 			fileData,
 			file.Size,
@@ -64,6 +75,11 @@ func UploadVideo(videoService *services.VideoService) fiber.Handler {
 		)
 
 		if err != nil {
+			if errors.Is(err, services.ErrInvalidUserID) {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "user_id (UUID) kerak",
+				})
+			}
 			return c.Status(500).JSON(fiber.Map{
 				"error": err.Error(),
 			})
@@ -78,18 +94,24 @@ func UploadVideo(videoService *services.VideoService) fiber.Handler {
 
 func GetVideos(videoService *services.VideoService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		limit := c.QueryInt("limit", 20)
+		limit, err := pageLimit(c, 20)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 
-		videos, err := videoService.GetVideos(c.Context(), limit)
+		videos, next, err := videoService.GetVideos(c.Context(), c.Query("cursor"), limit)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
+			return c.Status(listErrorStatus(err)).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
 		return c.JSON(fiber.Map{
-			"videos": videos,
-			"total":  len(videos),
+			"videos":      videos,
+			"total":       len(videos),
+			"next_cursor": next,
 		})
 	}
 }
@@ -141,10 +163,9 @@ func IncrementView(videoService *services.VideoService) fiber.Handler {
 	}
 }
 
-func StreamVideo(videoService *services.VideoService, minioClient *minio.Client) fiber.Handler {
+func StreamVideo(videoService *services.VideoService, store storage.ObjectStore, buckets config.Buckets) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		videoID := c.Params("id")
-		quality := c.Query("quality", "720p")
 
 		video, err := videoService.GetVideo(c.Context(), videoID)
 		if err != nil {
@@ -153,32 +174,28 @@ func StreamVideo(videoService *services.VideoService, minioClient *minio.Client)
 			})
 		}
 
-		// MinIOdan videoni stream qilish
-		objectPath := video.VideoURL
-		if quality != "" {
-			objectPath = video.QualityVersions[quality]
+		// Sifat berilmasa videoning standart sifati (video_url): manba 720p dan
+		// past bo'lsa u eng yuqori tayyor sifat
+		if c.Query("quality") == "" {
+			if asset, err := assets.ParseURL(video.VideoURL); err == nil && asset.Kind == assets.Renditions {
+				return serveAsset(c, store, buckets, asset)
+			}
 		}
 
-		object, err := minioClient.GetObject(c.Context(), "videos-processed", objectPath, minio.GetObjectOptions{})
+		asset, err := assets.Parse(video.ID, assets.Renditions, c.Query("quality", "720p")+".mp4")
 		if err != nil {
-			return c.Status(404).JSON(fiber.Map{
-				"error": "Video fayl topilmadi",
+			return c.Status(400).JSON(fiber.Map{
+				"error": "Noto'g'ri sifat",
 			})
 		}
-		defer object.Close()
-
-		c.Set("Content-Type", "video/mp4")
-		c.Set("Accept-Ranges", "bytes")
 
-		_, err = io.Copy(c.Response().BodyWriter(), object)
-		return err
+		return serveAsset(c, store, buckets, asset)
 	}
 }
 
 func SearchVideos(videoService *services.VideoService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		keyword := c.Query("q")
-		limit := c.QueryInt("limit", 20)
 
 		if keyword == "" {
 			return c.Status(400).JSON(fiber.Map{
@@ -186,34 +203,48 @@ func SearchVideos(videoService *services.VideoService) fiber.Handler {
 			})
 		}
 
-		videos, err := videoService.SearchVideos(c.Context(), keyword, limit)
+		limit, err := pageLimit(c, 20)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		videos, next, err := videoService.SearchVideos(c.Context(), keyword, c.Query("cursor"), limit)
+		if err != nil {
+			return c.Status(listErrorStatus(err)).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
 		return c.JSON(fiber.Map{
-			"results": videos,
-			"total":   len(videos),
+			"results":     videos,
+			"total":       len(videos),
+			"next_cursor": next,
 		})
 	}
 }
 
 func GetTrending(analyticsService *services.AnalyticsService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		limit := c.QueryInt("limit", 10)
+		limit, err := pageLimit(c, 10)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 
-		videos, err := analyticsService.GetTrendingVideos(c.Context(), limit)
+		videos, next, err := analyticsService.GetTrendingVideos(c.Context(), c.Query("cursor"), limit)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
+			return c.Status(listErrorStatus(err)).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 
 		return c.JSON(fiber.Map{
-			"trending": videos,
-			"total":    len(videos),
+			"trending":    videos,
+			"total":       len(videos),
+			"next_cursor": next,
 		})
 	}
 }
@@ -235,3 +266,115 @@ func GetVideoAnalytics(analyticsService *services.AnalyticsService) fiber.Handle
 		})
 	}
 }
+
+// GetVideoAudio "listen mode" uchun audio-only formatlar ro'yxati
+func GetVideoAudio(videoService *services.VideoService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		video, err := videoService.GetVideo(c.Context(), c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Video topilmadi",
+			})
+		}
+
+		if len(video.AudioVersions) == 0 {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Audio hali tayyor emas",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"video_id":      video.ID,
+			"title":         video.Title,
+			"duration":      video.Duration,
+			"thumbnail_url": video.ThumbnailURL,
+			"audio":         video.AudioVersions,
+		})
+	}
+}
+
+// GetThumbnails joriy thumbnail va avtomatik variantlar
+func GetThumbnails(videoService *services.VideoService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		video, err := videoService.GetVideo(c.Context(), c.Params("id"))
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "Video topilmadi",
+			})
+		}
+
+		candidates := video.ThumbnailCandidates
+		if candidates == nil {
+			candidates = []models.ThumbnailCandidate{}
+		}
+
+		return c.JSON(fiber.Map{
+			"video_id":      video.ID,
+			"thumbnail_url": video.ThumbnailURL,
+			"candidates":    candidates,
+		})
+	}
+}
+
+// SetThumbnail asosiy thumbnailni o'zgartiradi: JSON {"user_id", "candidate"}
+// bilan variant tanlanadi yoki multipart "thumbnail" fayli (va "user_id") yuklanadi.
+//
+// Diqqat: autentifikatsiya yo'q - user_id so'rovdan olinadi va faqat video
+// egasiga mosligi tekshiriladi (mos kelmasa 400). Bu himoya emas: video
+// user_id sini bilgan har qanday mijoz thumbnailni o'zgartira oladi.
+func SetThumbnail(videoService *services.VideoService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var (
+			video *models.Video
+			err   error
+		)
+
+		if file, fileErr := c.FormFile("thumbnail"); fileErr == nil {
+			fileData, openErr := file.Open()
+			if openErr != nil {
+				return c.Status(500).JSON(fiber.Map{
+					"error": "Fayl ochilmadi",
+				})
+			}
+			defer fileData.Close()
+
+			video, err = videoService.UploadThumbnail(c.Context(), c.Params("id"), c.FormValue("user_id"), fileData)
+		} else {
+			var req struct {
+				UserID    string `json:"user_id"`
+				Candidate *int   `json:"candidate"`
+			}
+			if err := c.BodyParser(&req); err != nil || req.Candidate == nil {
+				return c.Status(400).JSON(fiber.Map{
+					"error": "candidate yoki thumbnail fayli kerak",
+				})
+			}
+
+			video, err = videoService.SelectThumbnail(c.Context(), c.Params("id"), req.UserID, *req.Candidate)
+		}
+
+		if err != nil {
+			status := 500
+			switch {
+			case errors.Is(err, services.ErrOwnerMismatch):
+				status = 400
+			case errors.Is(err, services.ErrCandidateNotFound):
+				status = 404
+			case errors.Is(err, services.ErrInvalidThumbnail):
+				status = 400
+			case errors.Is(err, services.ErrThumbnailTooLarge):
+				status = 413
+			case errors.Is(err, repository.ErrNotFound):
+				status = 404
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"thumbnail_url": video.ThumbnailURL,
+			"candidates":    video.ThumbnailCandidates,
+		})
+	}
+}
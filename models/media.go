@@ -0,0 +1,70 @@
+// models/media.go
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// MediaInfo - ffprobe natijasi (manba fayl haqida)
+type MediaInfo struct {
+	Container string           `json:"container"` // masalan "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration  float64          `json:"duration"`  // soniya
+	Bitrate   int64            `json:"bitrate"`   // bit/s (umumiy)
+	Size      int64            `json:"size"`
+	Video     *VideoStreamInfo `json:"video,omitempty"`
+	Audio     *AudioStreamInfo `json:"audio,omitempty"`
+}
+
+type VideoStreamInfo struct {
+	Codec         string  `json:"codec"`
+	Profile       string  `json:"profile,omitempty"`
+	PixelFormat   string  `json:"pixel_format,omitempty"`
+	Width         int     `json:"width"`  // kodlangan o'lcham (rotationsiz)
+	Height        int     `json:"height"` // kodlangan o'lcham (rotationsiz)
+	SampleAspect  string  `json:"sample_aspect_ratio,omitempty"`
+	DisplayAspect string  `json:"display_aspect_ratio,omitempty"`
+	Rotation      int     `json:"rotation"` // 0, 90, 180, 270
+	FrameRate     float64 `json:"frame_rate"`
+	Bitrate       int64   `json:"bitrate"` // bit/s, noma'lum bo'lsa 0
+}
+
+type AudioStreamInfo struct {
+	Codec      string `json:"codec"`
+	Channels   int    `json:"channels"`
+	SampleRate int    `json:"sample_rate"`
+	Bitrate    int64  `json:"bitrate"`
+}
+
+// DisplaySize ekranda ko'rinadigan o'lcham: piksel nisbati (SAR) va
+// rotation hisobga olingan
+func (v VideoStreamInfo) DisplaySize() (width, height int) {
+	width, height = v.Width, v.Height
+
+	var num, den int
+	if n, _ := fmt.Sscanf(v.SampleAspect, "%d:%d", &num, &den); n == 2 && num > 0 && den > 0 && num != den {
+		width = width * num / den
+	}
+
+	if v.Rotation == 90 || v.Rotation == 270 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// ApplyMediaInfo probe natijasini videoning metadata maydonlariga yozadi
+func (v *Video) ApplyMediaInfo(info *MediaInfo) {
+	v.MediaInfo = info
+	v.Duration = int(math.Round(info.Duration))
+	v.Bitrate = info.Bitrate
+	v.Container = info.Container
+
+	if info.Video != nil {
+		v.Width, v.Height = info.Video.DisplaySize()
+		v.FPS = info.Video.FrameRate
+		v.VideoCodec = info.Video.Codec
+	}
+	if info.Audio != nil {
+		v.AudioCodec = info.Audio.Codec
+	}
+}
@@ -8,23 +8,58 @@ import (
 )
 
 type Video struct {
-	ID              gocql.UUID        `json:"id"`
-	Title           string            `json:"title"`
-	Description     string            `json:"description"`
-	UserID          gocql.UUID        `json:"user_id"`
-	Username        string            `json:"username"`
-	FileName        string            `json:"file_name"`
-	FileSize        int64             `json:"file_size"`
-	Duration        int               `json:"duration"`
-	ThumbnailURL    string            `json:"thumbnail_url"`
-	VideoURL        string            `json:"video_url"`
-	Status          string            `json:"status"` // uploading, processing, ready, failed
-	QualityVersions map[string]string `json:"quality_versions"`
-	Views           int64             `json:"views"`
-	Likes           int64             `json:"likes"`
-	Dislikes        int64             `json:"dislikes"`
-	CreatedAt       time.Time         `json:"created_at"`
-	UpdatedAt       time.Time         `json:"updated_at"`
+	ID                  gocql.UUID           `json:"id"`
+	Title               string               `json:"title"`
+	Description         string               `json:"description"`
+	UserID              gocql.UUID           `json:"user_id"`
+	Username            string               `json:"username"`
+	FileName            string               `json:"file_name"`
+	FileSize            int64                `json:"file_size"`
+	Duration            int                  `json:"duration"` // soniya
+	Width               int                  `json:"width"`
+	Height              int                  `json:"height"`
+	FPS                 float64              `json:"fps"`
+	VideoCodec          string               `json:"video_codec"`
+	AudioCodec          string               `json:"audio_codec"`
+	Bitrate             int64                `json:"bitrate"` // bit/s
+	Container           string               `json:"container"`
+	ThumbnailURL        string               `json:"thumbnail_url"`
+	ThumbnailCandidates []ThumbnailCandidate `json:"thumbnail_candidates,omitempty"` // avtomatik variantlar
+	PreviewTrackURL     string               `json:"preview_track_url,omitempty"`    // seek bar preview (WebVTT + sprite)
+	VideoURL            string               `json:"video_url"`
+	Status              string               `json:"status"` // uploading, processing, ready, failed
+	ErrorMessage        string               `json:"error_message,omitempty"`
+	QualityVersions     map[string]string    `json:"quality_versions"`
+	AudioVersions       map[string]string    `json:"audio_versions,omitempty"` // format -> URL ("hls" - audio playlist)
+	MediaInfo           *MediaInfo           `json:"media_info,omitempty"`
+	Views               int64                `json:"views"`
+	Likes               int64                `json:"likes"`
+	Dislikes            int64                `json:"dislikes"`
+	CreatedAt           time.Time            `json:"created_at"`
+	UpdatedAt           time.Time            `json:"updated_at"`
+}
+
+// VideoCounters - video_counters jadvalidagi hisoblagichlar. Cassandra COUNTER
+// ustunlarini oddiy ustunlar bilan bir jadvalda saqlashga ruxsat bermaydi.
+type VideoCounters struct {
+	Views    int64 `json:"views"`
+	Likes    int64 `json:"likes"`
+	Dislikes int64 `json:"dislikes"`
+}
+
+// ApplyCounters hisoblagichlarni video maydonlariga yozadi
+func (v *Video) ApplyCounters(counters VideoCounters) {
+	v.Views = counters.Views
+	v.Likes = counters.Likes
+	v.Dislikes = counters.Dislikes
+}
+
+// ThumbnailCandidate - videodan avtomatik olingan thumbnail varianti
+type ThumbnailCandidate struct {
+	URL      string  `json:"url"`
+	At       float64 `json:"at"`    // videodagi vaqt, soniya
+	Score    float64 `json:"score"` // kontrast bahosi; qora/bo'sh kadrlar past
+	Selected bool    `json:"selected"`
 }
 
 type VideoUploadRequest struct {
@@ -34,6 +69,33 @@ type VideoUploadRequest struct {
 	Username    string `json:"username" form:"username"`
 }
 
+// UploadMetadata resumable upload uchun video ma'lumotlari (tus Upload-Metadata)
+type UploadMetadata struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+	FileName    string `json:"file_name"`
+	FileType    string `json:"file_type"`
+}
+
+// UploadSessionRequest presigned upload sessiyasini ochish uchun so'rov
+type UploadSessionRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	UserID      string `json:"user_id"`
+	Username    string `json:"username"`
+	FileName    string `json:"file_name"`
+	FileType    string `json:"file_type"`
+	FileSize    int64  `json:"file_size"`
+}
+
+// UploadedPart klient MinIOga yuklagan part (PUT javobidagi ETag)
+type UploadedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
 type ProcessingJob struct {
 	JobID        gocql.UUID `json:"job_id"`
 	VideoID      gocql.UUID `json:"video_id"`
@@ -0,0 +1,358 @@
+// queue/queue.go
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLeaseLost - xabar boshqa workerga qaytarilgan (visibility timeout o'tgan)
+var ErrLeaseLost = errors.New("xabar lease muddati o'tgan")
+
+// Queue - Redis asosidagi ishonchli navbat.
+//
+// Dequeue xabarni atomik ravishda pending ro'yxatidan workerning processing
+// ro'yxatiga ko'chiradi va lease (visibility timeout) yozadi. Xabar faqat Ack
+// chaqirilganda o'chiriladi; lease muddati o'tgan xabarlar Reclaim orqali
+// navbatga qaytariladi. Shu sababli jarayon ishlash vaqtida tushib qolsa ham
+// job yo'qolmaydi.
+//
+// Xato bilan tugagan xabarlar Retry orqali kechiktirilgan (delayed) to'plamga,
+// urinishlar tugaganda esa DeadLetter orqali dead-letter ro'yxatiga o'tkaziladi.
+type Queue struct {
+	redis             *redis.Client
+	name              string
+	visibilityTimeout time.Duration
+}
+
+func New(redis *redis.Client, name string, visibilityTimeout time.Duration) *Queue {
+	return &Queue{
+		redis:             redis,
+		name:              name,
+		visibilityTimeout: visibilityTimeout,
+	}
+}
+
+func (q *Queue) Name() string { return q.name }
+
+func (q *Queue) pendingKey() string                   { return q.name }
+func (q *Queue) leasesKey() string                    { return q.name + ":leases" }
+func (q *Queue) ownersKey() string                    { return q.name + ":owners" }
+func (q *Queue) delayedKey() string                   { return q.name + ":delayed" }
+func (q *Queue) deadKey() string                      { return q.name + ":dead" }
+func (q *Queue) processingPrefix() string             { return q.name + ":processing:" }
+func (q *Queue) processingKey(workerID string) string { return q.processingPrefix() + workerID }
+
+// KEYS: pending, processing, leases, owners; ARGV: deadline, workerID
+var dequeueScript = redis.NewScript(`
+local payload = redis.call('LMOVE', KEYS[1], KEYS[2], 'LEFT', 'RIGHT')
+if payload then
+	redis.call('ZADD', KEYS[3], ARGV[1], payload)
+	redis.call('HSET', KEYS[4], payload, ARGV[2])
+end
+return payload
+`)
+
+// KEYS: processing, leases, owners; ARGV: payload, workerID
+var ackScript = redis.NewScript(`
+if redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+return 1
+`)
+
+// KEYS: leases, owners; ARGV: payload, workerID, deadline
+var extendScript = redis.NewScript(`
+if redis.call('HGET', KEYS[2], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[3], ARGV[1])
+return 1
+`)
+
+// KEYS: processing, leases, owners, target; ARGV: payload, workerID, new payload, score.
+// score bo'sh bo'lsa target ro'yxat (RPUSH), aks holda sorted set (ZADD).
+var releaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[3], ARGV[1]) ~= ARGV[2] then
+	return 0
+end
+redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+if ARGV[4] == '' then
+	redis.call('RPUSH', KEYS[4], ARGV[3])
+else
+	redis.call('ZADD', KEYS[4], ARGV[4], ARGV[3])
+end
+return 1
+`)
+
+// KEYS: delayed, pending; ARGV: now, limit
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, payload in ipairs(due) do
+	redis.call('ZREM', KEYS[1], payload)
+	redis.call('RPUSH', KEYS[2], payload)
+end
+return #due
+`)
+
+// KEYS: leases, owners, target; ARGV: payload, now, processing prefix, new payload.
+// Lease shu orada uzaytirilgan yoki boshqa replika qaytargan bo'lsa 0.
+var reclaimScript = redis.NewScript(`
+local score = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not score or tonumber(score) > tonumber(ARGV[2]) then
+	return 0
+end
+local owner = redis.call('HGET', KEYS[2], ARGV[1])
+if owner then
+	redis.call('LREM', ARGV[3] .. owner, 1, ARGV[1])
+end
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('RPUSH', KEYS[3], ARGV[4])
+return 1
+`)
+
+// Enqueue xabarni navbat oxiriga qo'shadi
+func (q *Queue) Enqueue(ctx context.Context, payload []byte) error {
+	return q.redis.RPush(ctx, q.pendingKey(), payload).Err()
+}
+
+// Dequeue navbatdan bitta xabarni oladi. Navbat bo'sh bo'lsa wait davomida
+// kutadi va "", nil qaytaradi.
+func (q *Queue) Dequeue(ctx context.Context, workerID string, wait time.Duration) (string, error) {
+	deadline := time.Now().Add(wait)
+
+	for {
+		payload, err := dequeueScript.Run(ctx, q.redis,
+			[]string{q.pendingKey(), q.processingKey(workerID), q.leasesKey(), q.ownersKey()},
+			q.leaseDeadline(), workerID,
+		).Text()
+		if err == nil {
+			return payload, nil
+		}
+		if err != redis.Nil {
+			return "", err
+		}
+
+		if time.Now().After(deadline) {
+			return "", nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// Ack muvaffaqiyatli bajarilgan xabarni o'chiradi
+func (q *Queue) Ack(ctx context.Context, workerID, payload string) error {
+	ok, err := ackScript.Run(ctx, q.redis,
+		[]string{q.processingKey(workerID), q.leasesKey(), q.ownersKey()},
+		payload, workerID,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Extend lease muddatini uzaytiradi (uzoq davom etadigan joblar uchun heartbeat)
+func (q *Queue) Extend(ctx context.Context, workerID, payload string) error {
+	ok, err := extendScript.Run(ctx, q.redis,
+		[]string{q.leasesKey(), q.ownersKey()},
+		payload, workerID, q.leaseDeadline(),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Heartbeat xabar ishlanayotgan vaqtda lease'ni davriy uzaytirib turadi.
+// Qaytarilgan funksiya heartbeatni to'xtatadi.
+func (q *Queue) Heartbeat(ctx context.Context, workerID, payload string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		ticker := time.NewTicker(q.visibilityTimeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := q.Extend(ctx, workerID, payload); errors.Is(err, ErrLeaseLost) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return cancel
+}
+
+// Retry xabarni ack qiladi va uning yangilangan nusxasini (masalan, oshirilgan
+// RetryCount bilan) delay o'tgandan keyin navbatga qaytishi uchun rejalashtiradi
+func (q *Queue) Retry(ctx context.Context, workerID, payload string, newPayload []byte, delay time.Duration) error {
+	readyAt := time.Now().Add(delay).UnixMilli()
+	return q.release(ctx, workerID, payload, newPayload, q.delayedKey(), fmt.Sprint(readyAt))
+}
+
+// DeadLetter urinishlari tugagan xabarni dead-letter ro'yxatiga o'tkazadi
+func (q *Queue) DeadLetter(ctx context.Context, workerID, payload string, newPayload []byte) error {
+	return q.release(ctx, workerID, payload, newPayload, q.deadKey(), "")
+}
+
+func (q *Queue) release(ctx context.Context, workerID, payload string, newPayload []byte, target, score string) error {
+	ok, err := releaseScript.Run(ctx, q.redis,
+		[]string{q.processingKey(workerID), q.leasesKey(), q.ownersKey(), target},
+		payload, workerID, newPayload, score,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// PromoteDelayed vaqti kelgan kechiktirilgan xabarlarni navbatga qaytaradi
+func (q *Queue) PromoteDelayed(ctx context.Context) (int, error) {
+	return promoteScript.Run(ctx, q.redis,
+		[]string{q.delayedKey(), q.pendingKey()},
+		time.Now().UnixMilli(), 100,
+	).Int()
+}
+
+// DeadLetters dead-letter ro'yxatidagi xabarlar
+func (q *Queue) DeadLetters(ctx context.Context, limit int64) ([]string, error) {
+	return q.redis.LRange(ctx, q.deadKey(), 0, limit-1).Result()
+}
+
+// ReclaimFunc lease muddati o'tgan xabarning yangilangan nusxasini (masalan,
+// oshirilgan RetryCount bilan) va uni dead-letterga o'tkazish kerakligini qaytaradi
+type ReclaimFunc func(payload string) (newPayload []byte, dead bool)
+
+// Reclaimed - Reclaim navbatga qaytargan yoki dead-letterga o'tkazgan xabar
+type Reclaimed struct {
+	Payload []byte
+	Dead    bool
+}
+
+// Reclaim lease muddati o'tgan xabarlarni next qaroriga ko'ra pending navbatga
+// yoki dead-letter ro'yxatiga o'tkazadi. Lease muddati o'tishi ham urinish
+// hisoblanadi: aks holda workerni yiqitadigan job cheksiz qayta olinadi.
+func (q *Queue) Reclaim(ctx context.Context, next ReclaimFunc) ([]Reclaimed, error) {
+	now := time.Now().UnixMilli()
+	expired, err := q.redis.ZRangeByScore(ctx, q.leasesKey(), &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   fmt.Sprint(now),
+		Count: 100,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var reclaimed []Reclaimed
+	for _, payload := range expired {
+		newPayload, dead := next(payload)
+		target := q.pendingKey()
+		if dead {
+			target = q.deadKey()
+		}
+
+		ok, err := reclaimScript.Run(ctx, q.redis,
+			[]string{q.leasesKey(), q.ownersKey(), target},
+			payload, now, q.processingPrefix(), newPayload,
+		).Int()
+		if err != nil {
+			return reclaimed, err
+		}
+		if ok == 1 {
+			reclaimed = append(reclaimed, Reclaimed{Payload: newPayload, Dead: dead})
+		}
+	}
+
+	return reclaimed, nil
+}
+
+// Stats - navbat holati (barcha replikalar bo'yicha)
+type Stats struct {
+	Pending  int64 `json:"pending"`   // olinishini kutayotgan
+	InFlight int64 `json:"in_flight"` // lease ostida ishlanayotgan
+	Delayed  int64 `json:"delayed"`   // retry kutayotgan
+	Dead     int64 `json:"dead"`      // dead-letter
+}
+
+// Stats navbat uzunliklarini bitta pipeline orqali oladi
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	pipe := q.redis.Pipeline()
+	pending := pipe.LLen(ctx, q.pendingKey())
+	inFlight := pipe.ZCard(ctx, q.leasesKey())
+	delayed := pipe.ZCard(ctx, q.delayedKey())
+	dead := pipe.LLen(ctx, q.deadKey())
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Stats{}, err
+	}
+
+	return Stats{
+		Pending:  pending.Val(),
+		InFlight: inFlight.Val(),
+		Delayed:  delayed.Val(),
+		Dead:     dead.Val(),
+	}, nil
+}
+
+func (q *Queue) leaseDeadline() int64 {
+	return time.Now().Add(q.visibilityTimeout).UnixMilli()
+}
+
+// Backoff attempt-urinish uchun kutish vaqti: base * 2^(attempt-1), max bilan
+// cheklangan. Bir vaqtda yiqilgan joblar bir paytda qaytmasligi uchun
+// qiymatning yarmi tasodifiy (equal jitter).
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// DefaultWorkerID host nomi va PID asosida worker ID yaratadi
+func DefaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
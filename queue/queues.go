@@ -0,0 +1,117 @@
+// queue/queues.go
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Queues - job turi bo'yicha alohida navbatlar. Har bir tur o'z navbatiga ega
+// bo'lgani uchun sekin joblar (transcode) tez joblarni (thumbnail) to'sib qo'ymaydi.
+type Queues map[string]*Queue
+
+// NewQueues har bir job turi uchun "<prefix>:<tur>" nomli navbat yaratadi
+func NewQueues(redis *redis.Client, prefix string, jobTypes []string, visibilityTimeout time.Duration) Queues {
+	queues := make(Queues, len(jobTypes))
+	for _, jobType := range jobTypes {
+		queues[jobType] = New(redis, prefix+":"+jobType, visibilityTimeout)
+	}
+	return queues
+}
+
+// For job turi navbatini qaytaradi
+func (qs Queues) For(jobType string) (*Queue, error) {
+	q, ok := qs[jobType]
+	if !ok {
+		return nil, fmt.Errorf("noma'lum job turi: %s", jobType)
+	}
+	return q, nil
+}
+
+// Types job turlari (tartiblangan)
+func (qs Queues) Types() []string {
+	types := make([]string, 0, len(qs))
+	for jobType := range qs {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// KEYS: source, target, owners; ARGV: payload, source turi (list|zset|lease), processing prefix
+var drainScript = redis.NewScript(`
+local removed
+if ARGV[2] == 'list' then
+	removed = redis.call('LREM', KEYS[1], 1, ARGV[1])
+else
+	removed = redis.call('ZREM', KEYS[1], ARGV[1])
+end
+if removed == 0 then
+	return 0
+end
+if ARGV[2] == 'lease' then
+	local owner = redis.call('HGET', KEYS[3], ARGV[1])
+	if owner then
+		redis.call('LREM', ARGV[3] .. owner, 1, ARGV[1])
+	end
+	redis.call('HDEL', KEYS[3], ARGV[1])
+end
+redis.call('RPUSH', KEYS[2], ARGV[1])
+return 1
+`)
+
+// DrainInto eski (bitta umumiy) navbatdagi barcha xabarlarni - pending,
+// kechiktirilgan, ishlanayotgan va dead-letter - route tanlagan navbatga
+// ko'chiradi. Dead-letter xabarlar maqsad navbatning dead-letter ro'yxatiga
+// tushadi. route false qaytargan xabar joyida qoladi.
+func (q *Queue) DrainInto(ctx context.Context, route func(payload string) (*Queue, bool)) (int, error) {
+	sources := []struct {
+		key, kind string
+		dead      bool
+	}{
+		{q.pendingKey(), "list", false},
+		{q.delayedKey(), "zset", false},
+		{q.leasesKey(), "lease", false},
+		{q.deadKey(), "list", true},
+	}
+
+	moved := 0
+	for _, source := range sources {
+		var payloads []string
+		var err error
+		if source.kind == "list" {
+			payloads, err = q.redis.LRange(ctx, source.key, 0, -1).Result()
+		} else {
+			payloads, err = q.redis.ZRange(ctx, source.key, 0, -1).Result()
+		}
+		if err != nil {
+			return moved, err
+		}
+
+		for _, payload := range payloads {
+			target, ok := route(payload)
+			if !ok {
+				continue
+			}
+			targetKey := target.pendingKey()
+			if source.dead {
+				targetKey = target.deadKey()
+			}
+
+			n, err := drainScript.Run(ctx, q.redis,
+				[]string{source.key, targetKey, q.ownersKey()},
+				payload, source.kind, q.processingPrefix(),
+			).Int()
+			if err != nil {
+				return moved, err
+			}
+			moved += n
+		}
+	}
+
+	return moved, nil
+}
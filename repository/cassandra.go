@@ -0,0 +1,512 @@
+// repository/cassandra.go
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/gocql/gocql"
+)
+
+// NewCassandraRepositories Cassandra asosidagi repositorylarni yaratadi.
+// Yozishlar sessiyaning default consistency darajasida bajariladi, o'qishlar
+// esa readConsistency bilan (masalan LOCAL_QUORUM - boshqa DC ni kutmaslik uchun).
+func NewCassandraRepositories(session *gocql.Session, readConsistency gocql.Consistency) Repositories {
+	base := cassandraBase{session: session, readConsistency: readConsistency}
+	return Repositories{
+		Videos:       &cassandraVideos{base},
+		Counters:     &cassandraCounters{base},
+		VideosByUser: &cassandraVideosByUser{base},
+		Analytics:    &cassandraAnalytics{base},
+		Search:       &cassandraSearch{base},
+		Comments:     &cassandraComments{base},
+		Jobs:         &cassandraJobs{base},
+	}
+}
+
+// cassandraBase barcha Cassandra repositorylari uchun umumiy sessiya
+type cassandraBase struct {
+	session         *gocql.Session
+	readConsistency gocql.Consistency
+}
+
+// read o'qish so'rovi (SELECT) - o'qish consistency darajasi bilan
+func (b cassandraBase) read(stmt string, values ...interface{}) *gocql.Query {
+	return b.session.Query(stmt, values...).Consistency(b.readConsistency)
+}
+
+func mapError(err error) error {
+	if err == gocql.ErrNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// readPage o'qish so'rovining bitta sahifasi. PageState berilganda gocql
+// keyingi sahifalarni avtomatik yuklamaydi, shuning uchun iter faqat shu
+// sahifa qatorlarini qaytaradi.
+func (b cassandraBase) readPage(ctx context.Context, page PageRequest, stmt string, values ...interface{}) *gocql.Iter {
+	return b.read(stmt, values...).WithContext(ctx).PageSize(page.Size).PageState(page.State).Iter()
+}
+
+// closePage iterni yopadi va keyingi sahifa holatini qaytaradi. Klient
+// buzgan holatni Cassandra so'rov xatosi sifatida rad etadi.
+func closePage(iter *gocql.Iter, page PageRequest) ([]byte, error) {
+	next := iter.PageState()
+	if err := iter.Close(); err != nil {
+		var reqErr gocql.RequestError
+		if len(page.State) > 0 && errors.As(err, &reqErr) &&
+			(reqErr.Code() == gocql.ErrCodeInvalid || reqErr.Code() == gocql.ErrCodeProtocol) {
+			return nil, ErrInvalidPageState
+		}
+		return nil, err
+	}
+	if len(next) == 0 {
+		return nil, nil
+	}
+	return next, nil
+}
+
+// Videos
+
+type cassandraVideos struct {
+	cassandraBase
+}
+
+func (r *cassandraVideos) Create(ctx context.Context, video *models.Video) error {
+	query := `INSERT INTO videos (id, title, description, user_id, username, file_name,
+		file_size, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	return r.session.Query(query, video.ID, video.Title, video.Description,
+		video.UserID, video.Username, video.FileName, video.FileSize,
+		video.Status, video.CreatedAt, video.UpdatedAt).WithContext(ctx).Exec()
+}
+
+func (r *cassandraVideos) Get(ctx context.Context, id gocql.UUID) (*models.Video, error) {
+	var video models.Video
+	query := `SELECT id, title, description, user_id, username, file_name, file_size,
+		duration, width, height, fps, video_codec, audio_codec, bitrate, container,
+		thumbnail_url, thumbnail_candidates, preview_track_url, video_url, status, error_message, quality_versions, audio_versions, media_info,
+		created_at, updated_at
+		FROM videos WHERE id = ?`
+
+	var mediaInfo, candidates string
+	err := r.read(query, id).WithContext(ctx).Scan(
+		&video.ID, &video.Title, &video.Description, &video.UserID, &video.Username,
+		&video.FileName, &video.FileSize,
+		&video.Duration, &video.Width, &video.Height, &video.FPS,
+		&video.VideoCodec, &video.AudioCodec, &video.Bitrate, &video.Container,
+		&video.ThumbnailURL, &candidates, &video.PreviewTrackURL, &video.VideoURL, &video.Status, &video.ErrorMessage,
+		&video.QualityVersions, &video.AudioVersions, &mediaInfo, &video.CreatedAt, &video.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	if mediaInfo != "" {
+		video.MediaInfo = &models.MediaInfo{}
+		if err := json.Unmarshal([]byte(mediaInfo), video.MediaInfo); err != nil {
+			video.MediaInfo = nil
+		}
+	}
+
+	if candidates != "" {
+		if err := json.Unmarshal([]byte(candidates), &video.ThumbnailCandidates); err != nil {
+			video.ThumbnailCandidates = nil
+		}
+	}
+
+	return &video, nil
+}
+
+func (r *cassandraVideos) List(ctx context.Context, page PageRequest) ([]models.Video, []byte, error) {
+	query := "SELECT id, title, description, username, thumbnail_url, video_url, duration, created_at FROM videos"
+	iter := r.readPage(ctx, page, query)
+
+	var videos []models.Video
+	var video models.Video
+
+	for iter.Scan(&video.ID, &video.Title, &video.Description, &video.Username,
+		&video.ThumbnailURL, &video.VideoURL, &video.Duration, &video.CreatedAt) {
+		videos = append(videos, video)
+		video = models.Video{}
+	}
+
+	next, err := closePage(iter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+	return videos, next, nil
+}
+
+// MarkReady transcode natijasini saqlaydi. thumbnail_url ga tegmaydi - uni
+// thumbnail job yoki video egasi mustaqil o'zgartiradi.
+func (r *cassandraVideos) MarkReady(ctx context.Context, id gocql.UUID, videoURL string, qualityVersions map[string]string) error {
+	query := `UPDATE videos SET status = ?, video_url = ?, quality_versions = ?,
+		updated_at = ? WHERE id = ?`
+	return r.session.Query(query, "ready", videoURL, qualityVersions, time.Now(), id).WithContext(ctx).Exec()
+}
+
+func (r *cassandraVideos) SetStatus(ctx context.Context, id gocql.UUID, status string) error {
+	query := "UPDATE videos SET status = ?, updated_at = ? WHERE id = ?"
+	return r.session.Query(query, status, time.Now(), id).WithContext(ctx).Exec()
+}
+
+func (r *cassandraVideos) MarkFailed(ctx context.Context, id gocql.UUID, errorMessage string) error {
+	query := "UPDATE videos SET status = ?, error_message = ?, updated_at = ? WHERE id = ?"
+	return r.session.Query(query, "failed", errorMessage, time.Now(), id).WithContext(ctx).Exec()
+}
+
+// SetMediaInfo ffprobe natijasini asosiy maydonlarga va to'liq holda JSON
+// ko'rinishida saqlaydi
+func (r *cassandraVideos) SetMediaInfo(ctx context.Context, id gocql.UUID, info *models.MediaInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	var video models.Video
+	video.ApplyMediaInfo(info)
+
+	query := `UPDATE videos SET duration = ?, width = ?, height = ?, fps = ?, video_codec = ?,
+		audio_codec = ?, bitrate = ?, container = ?, media_info = ?, updated_at = ? WHERE id = ?`
+	return r.session.Query(query, video.Duration, video.Width, video.Height, video.FPS,
+		video.VideoCodec, video.AudioCodec, video.Bitrate, video.Container,
+		string(data), time.Now(), id).WithContext(ctx).Exec()
+}
+
+func (r *cassandraVideos) SetAudioVersions(ctx context.Context, id gocql.UUID, versions map[string]string) error {
+	query := "UPDATE videos SET audio_versions = ?, updated_at = ? WHERE id = ?"
+	return r.session.Query(query, versions, time.Now(), id).WithContext(ctx).Exec()
+}
+
+func (r *cassandraVideos) SetPreviewTrack(ctx context.Context, id gocql.UUID, trackURL string) error {
+	query := "UPDATE videos SET preview_track_url = ?, updated_at = ? WHERE id = ?"
+	return r.session.Query(query, trackURL, time.Now(), id).WithContext(ctx).Exec()
+}
+
+func (r *cassandraVideos) SetThumbnail(ctx context.Context, id gocql.UUID, thumbnailURL string, candidates []models.ThumbnailCandidate) error {
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return err
+	}
+
+	// Thumbnail ustunlariga barcha yozuvlar LWT orqali (SetThumbnailIf bilan
+	// bir xil Paxos tartibida bo'lishi uchun)
+	query := "UPDATE videos SET thumbnail_url = ?, thumbnail_candidates = ?, updated_at = ? WHERE id = ? IF EXISTS"
+	applied, err := r.session.Query(query, thumbnailURL, string(data), time.Now(), id).WithContext(ctx).
+		MapScanCAS(make(map[string]interface{}))
+	if err != nil {
+		return err
+	}
+	if !applied {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *cassandraVideos) SetThumbnailIf(ctx context.Context, id gocql.UUID, thumbnailURL string, candidates []models.ThumbnailCandidate, expectedURL string) (bool, error) {
+	data, err := json.Marshal(candidates)
+	if err != nil {
+		return false, err
+	}
+
+	// Thumbnail hech qachon yozilmagan bo'lsa ustun null
+	var expected interface{}
+	if expectedURL != "" {
+		expected = expectedURL
+	}
+
+	query := "UPDATE videos SET thumbnail_url = ?, thumbnail_candidates = ?, updated_at = ? WHERE id = ? IF thumbnail_url = ?"
+	return r.session.Query(query, thumbnailURL, string(data), time.Now(), id, expected).WithContext(ctx).
+		MapScanCAS(make(map[string]interface{}))
+}
+
+func (r *cassandraVideos) Delete(ctx context.Context, id gocql.UUID) error {
+	query := "DELETE FROM videos WHERE id = ?"
+	return r.session.Query(query, id).WithContext(ctx).Exec()
+}
+
+// Video counters
+
+type cassandraCounters struct {
+	cassandraBase
+}
+
+func (r *cassandraCounters) Get(ctx context.Context, id gocql.UUID) (models.VideoCounters, error) {
+	var counters models.VideoCounters
+	query := "SELECT views, likes, dislikes FROM video_counters WHERE video_id = ?"
+
+	err := r.read(query, id).WithContext(ctx).Scan(&counters.Views, &counters.Likes, &counters.Dislikes)
+	if err != nil && err != gocql.ErrNotFound {
+		return counters, err
+	}
+	return counters, nil
+}
+
+func (r *cassandraCounters) GetMany(ctx context.Context, ids []gocql.UUID) (map[gocql.UUID]models.VideoCounters, error) {
+	result := make(map[gocql.UUID]models.VideoCounters, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	query := "SELECT video_id, views, likes, dislikes FROM video_counters WHERE video_id IN ?"
+	iter := r.read(query, ids).WithContext(ctx).Iter()
+
+	var id gocql.UUID
+	var counters models.VideoCounters
+	for iter.Scan(&id, &counters.Views, &counters.Likes, &counters.Dislikes) {
+		result[id] = counters
+		counters = models.VideoCounters{}
+	}
+
+	return result, iter.Close()
+}
+
+func (r *cassandraCounters) IncrementViews(ctx context.Context, id gocql.UUID, n int64) error {
+	query := "UPDATE video_counters SET views = views + ? WHERE video_id = ?"
+	return r.session.Query(query, n, id).WithContext(ctx).Exec()
+}
+
+func (r *cassandraCounters) Delete(ctx context.Context, id gocql.UUID) error {
+	query := "DELETE FROM video_counters WHERE video_id = ?"
+	return r.session.Query(query, id).WithContext(ctx).Exec()
+}
+
+// Videos by user
+
+type cassandraVideosByUser struct {
+	cassandraBase
+}
+
+func (r *cassandraVideosByUser) Add(ctx context.Context, video *models.Video) error {
+	query := `INSERT INTO videos_by_user (user_id, created_at, video_id, title, thumbnail_url)
+		VALUES (?, ?, ?, ?, ?)`
+	return r.session.Query(query, video.UserID, video.CreatedAt, video.ID,
+		video.Title, video.ThumbnailURL).WithContext(ctx).Exec()
+}
+
+func (r *cassandraVideosByUser) Remove(ctx context.Context, userID gocql.UUID, createdAt time.Time, videoID gocql.UUID) error {
+	query := "DELETE FROM videos_by_user WHERE user_id = ? AND created_at = ? AND video_id = ?"
+	return r.session.Query(query, userID, createdAt, videoID).WithContext(ctx).Exec()
+}
+
+func (r *cassandraVideosByUser) List(ctx context.Context, userID gocql.UUID, after *UserVideosCursor, limit int) ([]models.Video, error) {
+	const columns = "SELECT video_id, title, thumbnail_url, created_at FROM videos_by_user "
+	if after == nil {
+		return r.scan(ctx, userID, columns+"WHERE user_id = ? LIMIT ?", userID, limit)
+	}
+
+	// Avval kursor bilan bir xil created_at dagi qolgan qatorlar (video_id ASC),
+	// keyin undan eskilari - shunda bir millisekundda yuklangan videolar ham o'tkazib yuborilmaydi
+	videos, err := r.scan(ctx, userID, columns+"WHERE user_id = ? AND created_at = ? AND video_id > ? LIMIT ?",
+		userID, after.CreatedAt, after.VideoID, limit)
+	if err != nil || len(videos) >= limit {
+		return videos, err
+	}
+
+	older, err := r.scan(ctx, userID, columns+"WHERE user_id = ? AND created_at < ? LIMIT ?",
+		userID, after.CreatedAt, limit-len(videos))
+	return append(videos, older...), err
+}
+
+func (r *cassandraVideosByUser) scan(ctx context.Context, userID gocql.UUID, query string, values ...interface{}) ([]models.Video, error) {
+	iter := r.read(query, values...).WithContext(ctx).Iter()
+
+	var videos []models.Video
+	video := models.Video{UserID: userID}
+
+	for iter.Scan(&video.ID, &video.Title, &video.ThumbnailURL, &video.CreatedAt) {
+		videos = append(videos, video)
+		video = models.Video{UserID: userID}
+	}
+
+	return videos, iter.Close()
+}
+
+// Analytics
+
+type cassandraAnalytics struct {
+	cassandraBase
+}
+
+// SetTrending bucketni bitta partition batch bilan qayta yozadi. views
+// clustering kalitda bo'lgani uchun eski qatorlarni yangilab bo'lmaydi:
+// partition o'chiriladi (ts-1) va yangi qatorlar (ts) yoziladi - o'quvchi
+// yarim yozilgan ro'yxatni ko'rmaydi.
+func (r *cassandraAnalytics) SetTrending(ctx context.Context, timeBucket string, videos []models.Video) error {
+	batch := r.session.NewBatch(gocql.UnloggedBatch).WithContext(ctx)
+	ts := time.Now().UnixMicro()
+
+	batch.Query("DELETE FROM trending_videos USING TIMESTAMP ? WHERE time_bucket = ?", ts-1, timeBucket)
+	for _, video := range videos {
+		batch.Query(`INSERT INTO trending_videos (time_bucket, views, video_id, title,
+			thumbnail_url, created_at) VALUES (?, ?, ?, ?, ?, ?) USING TIMESTAMP ?`,
+			timeBucket, video.Views, video.ID, video.Title, video.ThumbnailURL, video.CreatedAt, ts)
+	}
+
+	return r.session.ExecuteBatch(batch)
+}
+
+func (r *cassandraAnalytics) ListTrending(ctx context.Context, timeBucket string, page PageRequest) ([]models.Video, []byte, error) {
+	query := `SELECT video_id, title, thumbnail_url, views, created_at
+		FROM trending_videos WHERE time_bucket = ?`
+	iter := r.readPage(ctx, page, query, timeBucket)
+
+	var videos []models.Video
+	var video models.Video
+
+	for iter.Scan(&video.ID, &video.Title, &video.ThumbnailURL, &video.Views, &video.CreatedAt) {
+		videos = append(videos, video)
+		video = models.Video{}
+	}
+
+	next, err := closePage(iter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+	return videos, next, nil
+}
+
+func (r *cassandraAnalytics) ListVideoAnalytics(ctx context.Context, videoID gocql.UUID, since time.Time) ([]models.VideoAnalytics, error) {
+	query := `SELECT video_id, date, hour, views, watch_time, likes, shares
+		FROM video_analytics WHERE video_id = ? AND date >= ?`
+	iter := r.read(query, videoID, since).WithContext(ctx).Iter()
+
+	var analytics []models.VideoAnalytics
+	var stat models.VideoAnalytics
+
+	for iter.Scan(&stat.VideoID, &stat.Date, &stat.Hour,
+		&stat.Views, &stat.WatchTime, &stat.Likes, &stat.Shares) {
+		analytics = append(analytics, stat)
+		stat = models.VideoAnalytics{}
+	}
+
+	return analytics, iter.Close()
+}
+
+// Search
+
+type cassandraSearch struct {
+	cassandraBase
+}
+
+func (r *cassandraSearch) Index(ctx context.Context, keyword string, video *models.Video) error {
+	query := `INSERT INTO video_search (keyword, video_id, title, thumbnail_url, created_at)
+		VALUES (?, ?, ?, ?, ?)`
+	return r.session.Query(query, keyword, video.ID, video.Title,
+		video.ThumbnailURL, video.CreatedAt).WithContext(ctx).Exec()
+}
+
+func (r *cassandraSearch) Remove(ctx context.Context, keyword string, createdAt time.Time, videoID gocql.UUID) error {
+	query := "DELETE FROM video_search WHERE keyword = ? AND created_at = ? AND video_id = ?"
+	return r.session.Query(query, keyword, createdAt, videoID).WithContext(ctx).Exec()
+}
+
+func (r *cassandraSearch) Search(ctx context.Context, keyword string, page PageRequest) ([]models.Video, []byte, error) {
+	query := "SELECT video_id, title, thumbnail_url, created_at FROM video_search WHERE keyword = ?"
+	iter := r.readPage(ctx, page, query, keyword)
+
+	var videos []models.Video
+	var video models.Video
+
+	for iter.Scan(&video.ID, &video.Title, &video.ThumbnailURL, &video.CreatedAt) {
+		videos = append(videos, video)
+		video = models.Video{}
+	}
+
+	next, err := closePage(iter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+	return videos, next, nil
+}
+
+// Comments
+
+type cassandraComments struct {
+	cassandraBase
+}
+
+func (r *cassandraComments) Add(ctx context.Context, comment *models.Comment) error {
+	query := `INSERT INTO comments (video_id, created_at, comment_id, user_id, username, text)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	return r.session.Query(query, comment.VideoID, comment.CreatedAt, comment.CommentID,
+		comment.UserID, comment.Username, comment.Text).WithContext(ctx).Exec()
+}
+
+func (r *cassandraComments) List(ctx context.Context, videoID gocql.UUID, limit int) ([]models.Comment, error) {
+	query := `SELECT video_id, comment_id, user_id, username, text, created_at
+		FROM comments WHERE video_id = ? LIMIT ?`
+	iter := r.read(query, videoID, limit).WithContext(ctx).Iter()
+
+	var comments []models.Comment
+	var comment models.Comment
+
+	for iter.Scan(&comment.VideoID, &comment.CommentID, &comment.UserID,
+		&comment.Username, &comment.Text, &comment.CreatedAt) {
+		comments = append(comments, comment)
+		comment = models.Comment{}
+	}
+
+	return comments, iter.Close()
+}
+
+// Processing jobs
+
+type cassandraJobs struct {
+	cassandraBase
+}
+
+// Save jobni ikkala jadvalga logged batch orqali yozadi
+func (r *cassandraJobs) Save(ctx context.Context, job *models.ProcessingJob) error {
+	batch := r.session.NewBatch(gocql.LoggedBatch).WithContext(ctx)
+
+	batch.Query(`INSERT INTO processing_jobs (job_id, video_id, job_type, status, priority,
+		retry_count, error_message, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.JobID, job.VideoID, job.JobType, job.Status, job.Priority,
+		job.RetryCount, job.ErrorMessage, job.CreatedAt, job.UpdatedAt)
+
+	batch.Query(`INSERT INTO processing_jobs_by_video (video_id, job_id, job_type, status, priority,
+		retry_count, error_message, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.VideoID, job.JobID, job.JobType, job.Status, job.Priority,
+		job.RetryCount, job.ErrorMessage, job.CreatedAt, job.UpdatedAt)
+
+	return r.session.ExecuteBatch(batch)
+}
+
+func (r *cassandraJobs) Get(ctx context.Context, jobID gocql.UUID) (*models.ProcessingJob, error) {
+	var job models.ProcessingJob
+	query := `SELECT job_id, video_id, job_type, status, priority, retry_count,
+		error_message, created_at, updated_at FROM processing_jobs WHERE job_id = ?`
+
+	err := r.read(query, jobID).WithContext(ctx).Scan(
+		&job.JobID, &job.VideoID, &job.JobType, &job.Status, &job.Priority,
+		&job.RetryCount, &job.ErrorMessage, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &job, nil
+}
+
+func (r *cassandraJobs) ListByVideo(ctx context.Context, videoID gocql.UUID) ([]models.ProcessingJob, error) {
+	query := `SELECT job_id, video_id, job_type, status, priority, retry_count,
+		error_message, created_at, updated_at FROM processing_jobs_by_video WHERE video_id = ?`
+	iter := r.read(query, videoID).WithContext(ctx).Iter()
+
+	var jobs []models.ProcessingJob
+	var job models.ProcessingJob
+
+	for iter.Scan(&job.JobID, &job.VideoID, &job.JobType, &job.Status, &job.Priority,
+		&job.RetryCount, &job.ErrorMessage, &job.CreatedAt, &job.UpdatedAt) {
+		jobs = append(jobs, job)
+		job = models.ProcessingJob{}
+	}
+
+	return jobs, iter.Close()
+}
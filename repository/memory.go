@@ -0,0 +1,502 @@
+// repository/memory.go
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/gocql/gocql"
+)
+
+// NewMemoryRepositories xotirada ishlaydigan (thread-safe) repositorylarni yaratadi.
+// Testlar va Cassandrasiz development uchun.
+func NewMemoryRepositories() Repositories {
+	videos := &memoryVideos{videos: make(map[gocql.UUID]models.Video)}
+
+	return Repositories{
+		Videos:       videos,
+		Counters:     &memoryCounters{counters: make(map[gocql.UUID]models.VideoCounters)},
+		VideosByUser: &memoryVideosByUser{videos: make(map[gocql.UUID][]models.Video)},
+		Analytics: &memoryAnalytics{
+			trending:  make(map[string]map[gocql.UUID]models.Video),
+			analytics: make(map[gocql.UUID][]models.VideoAnalytics),
+		},
+		Search:   &memorySearch{index: make(map[string]map[gocql.UUID]models.Video)},
+		Comments: &memoryComments{comments: make(map[gocql.UUID][]models.Comment)},
+		Jobs:     &memoryJobs{jobs: make(map[gocql.UUID]models.ProcessingJob)},
+	}
+}
+
+// sortByViews videolarni views bo'yicha kamayish tartibida saralaydi
+func sortByViews(videos []models.Video) {
+	sort.SliceStable(videos, func(i, j int) bool {
+		if videos[i].Views != videos[j].Views {
+			return videos[i].Views > videos[j].Views
+		}
+		return videos[i].ID.String() < videos[j].ID.String()
+	})
+}
+
+// sortNewestFirst created_at DESC, tengda video_id bo'yicha (sahifalar barqaror bo'lishi uchun)
+func sortNewestFirst(videos []models.Video) {
+	sort.Slice(videos, func(i, j int) bool {
+		if !videos[i].CreatedAt.Equal(videos[j].CreatedAt) {
+			return videos[i].CreatedAt.After(videos[j].CreatedAt)
+		}
+		return videos[i].ID.String() < videos[j].ID.String()
+	})
+}
+
+// pageVideos saralangan ro'yxatdan bitta sahifa. Holat - keyingi sahifa boshlanadigan indeks.
+func pageVideos(videos []models.Video, page PageRequest) ([]models.Video, []byte, error) {
+	offset := 0
+	if len(page.State) > 0 {
+		n, err := strconv.Atoi(string(page.State))
+		if err != nil || n < 0 {
+			return nil, nil, ErrInvalidPageState
+		}
+		offset = n
+	}
+	if offset >= len(videos) {
+		return nil, nil, nil
+	}
+
+	end := len(videos)
+	if page.Size > 0 && offset+page.Size < end {
+		end = offset + page.Size
+	}
+
+	var next []byte
+	if end < len(videos) {
+		next = []byte(strconv.Itoa(end))
+	}
+	return videos[offset:end], next, nil
+}
+
+func limitVideos(videos []models.Video, limit int) []models.Video {
+	if limit > 0 && len(videos) > limit {
+		return videos[:limit]
+	}
+	return videos
+}
+
+// Videos
+
+type memoryVideos struct {
+	mu     sync.RWMutex
+	videos map[gocql.UUID]models.Video
+}
+
+func (r *memoryVideos) Create(ctx context.Context, video *models.Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.videos[video.ID] = *video
+	return nil
+}
+
+func (r *memoryVideos) Get(ctx context.Context, id gocql.UUID) (*models.Video, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	video, ok := r.videos[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &video, nil
+}
+
+func (r *memoryVideos) List(ctx context.Context, page PageRequest) ([]models.Video, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	videos := make([]models.Video, 0, len(r.videos))
+	for _, video := range r.videos {
+		videos = append(videos, video)
+	}
+	sortNewestFirst(videos)
+
+	return pageVideos(videos, page)
+}
+
+func (r *memoryVideos) update(id gocql.UUID, fn func(*models.Video)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	video, ok := r.videos[id]
+	if !ok {
+		return ErrNotFound
+	}
+	fn(&video)
+	r.videos[id] = video
+	return nil
+}
+
+func (r *memoryVideos) MarkReady(ctx context.Context, id gocql.UUID, videoURL string, qualityVersions map[string]string) error {
+	return r.update(id, func(v *models.Video) {
+		v.Status = "ready"
+		v.VideoURL = videoURL
+		v.QualityVersions = qualityVersions
+		v.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memoryVideos) SetStatus(ctx context.Context, id gocql.UUID, status string) error {
+	return r.update(id, func(v *models.Video) {
+		v.Status = status
+		v.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memoryVideos) MarkFailed(ctx context.Context, id gocql.UUID, errorMessage string) error {
+	return r.update(id, func(v *models.Video) {
+		v.Status = "failed"
+		v.ErrorMessage = errorMessage
+		v.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memoryVideos) SetMediaInfo(ctx context.Context, id gocql.UUID, info *models.MediaInfo) error {
+	return r.update(id, func(v *models.Video) {
+		v.ApplyMediaInfo(info)
+		v.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memoryVideos) SetAudioVersions(ctx context.Context, id gocql.UUID, versions map[string]string) error {
+	return r.update(id, func(v *models.Video) {
+		v.AudioVersions = versions
+		v.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memoryVideos) SetPreviewTrack(ctx context.Context, id gocql.UUID, trackURL string) error {
+	return r.update(id, func(v *models.Video) {
+		v.PreviewTrackURL = trackURL
+		v.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memoryVideos) SetThumbnail(ctx context.Context, id gocql.UUID, thumbnailURL string, candidates []models.ThumbnailCandidate) error {
+	return r.update(id, func(v *models.Video) {
+		v.ThumbnailURL = thumbnailURL
+		v.ThumbnailCandidates = candidates
+		v.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memoryVideos) SetThumbnailIf(ctx context.Context, id gocql.UUID, thumbnailURL string, candidates []models.ThumbnailCandidate, expectedURL string) (bool, error) {
+	applied := false
+	err := r.update(id, func(v *models.Video) {
+		if v.ThumbnailURL != expectedURL {
+			return
+		}
+		v.ThumbnailURL = thumbnailURL
+		v.ThumbnailCandidates = candidates
+		v.UpdatedAt = time.Now()
+		applied = true
+	})
+	return applied, err
+}
+
+func (r *memoryVideos) Delete(ctx context.Context, id gocql.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.videos, id)
+	return nil
+}
+
+// Video counters
+
+type memoryCounters struct {
+	mu       sync.RWMutex
+	counters map[gocql.UUID]models.VideoCounters
+}
+
+func (r *memoryCounters) Get(ctx context.Context, id gocql.UUID) (models.VideoCounters, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.counters[id], nil
+}
+
+func (r *memoryCounters) GetMany(ctx context.Context, ids []gocql.UUID) (map[gocql.UUID]models.VideoCounters, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[gocql.UUID]models.VideoCounters, len(ids))
+	for _, id := range ids {
+		if counters, ok := r.counters[id]; ok {
+			result[id] = counters
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryCounters) IncrementViews(ctx context.Context, id gocql.UUID, n int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counters := r.counters[id]
+	counters.Views += n
+	r.counters[id] = counters
+	return nil
+}
+
+func (r *memoryCounters) Delete(ctx context.Context, id gocql.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.counters, id)
+	return nil
+}
+
+// Videos by user
+
+type memoryVideosByUser struct {
+	mu     sync.RWMutex
+	videos map[gocql.UUID][]models.Video
+}
+
+func (r *memoryVideosByUser) Add(ctx context.Context, video *models.Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := models.Video{
+		ID:           video.ID,
+		UserID:       video.UserID,
+		Title:        video.Title,
+		ThumbnailURL: video.ThumbnailURL,
+		CreatedAt:    video.CreatedAt,
+	}
+
+	list := r.videos[video.UserID]
+	for i, v := range list {
+		if v.ID == video.ID {
+			list[i] = entry
+			return nil
+		}
+	}
+
+	// Cassandra clustering tartibini saqlash: created_at DESC, video_id ASC
+	list = append(list, entry)
+	sort.SliceStable(list, func(i, j int) bool {
+		return userVideoBefore(list[i], list[j].CreatedAt, list[j].ID)
+	})
+	r.videos[video.UserID] = list
+	return nil
+}
+
+func (r *memoryVideosByUser) Remove(ctx context.Context, userID gocql.UUID, createdAt time.Time, videoID gocql.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.videos[userID]
+	for i, v := range list {
+		if v.ID == videoID {
+			r.videos[userID] = append(list[:i:i], list[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *memoryVideosByUser) List(ctx context.Context, userID gocql.UUID, after *UserVideosCursor, limit int) ([]models.Video, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []models.Video
+	for _, video := range r.videos[userID] {
+		if after == nil || userVideoBefore(models.Video{ID: after.VideoID, CreatedAt: after.CreatedAt}, video.CreatedAt, video.ID) {
+			videos = append(videos, video)
+		}
+	}
+	return limitVideos(videos, limit), nil
+}
+
+// userVideoBefore video (createdAt, id) qatoridan oldin turadimi
+func userVideoBefore(video models.Video, createdAt time.Time, id gocql.UUID) bool {
+	if !video.CreatedAt.Equal(createdAt) {
+		return video.CreatedAt.After(createdAt)
+	}
+	return video.ID.String() < id.String()
+}
+
+// Analytics
+
+type memoryAnalytics struct {
+	mu        sync.RWMutex
+	trending  map[string]map[gocql.UUID]models.Video
+	analytics map[gocql.UUID][]models.VideoAnalytics
+}
+
+func (r *memoryAnalytics) SetTrending(ctx context.Context, timeBucket string, videos []models.Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket := make(map[gocql.UUID]models.Video, len(videos))
+	for _, video := range videos {
+		bucket[video.ID] = models.Video{
+			ID:           video.ID,
+			Title:        video.Title,
+			ThumbnailURL: video.ThumbnailURL,
+			CreatedAt:    video.CreatedAt,
+			Views:        video.Views,
+		}
+	}
+	r.trending[timeBucket] = bucket
+	return nil
+}
+
+func (r *memoryAnalytics) ListTrending(ctx context.Context, timeBucket string, page PageRequest) ([]models.Video, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []models.Video
+	for _, video := range r.trending[timeBucket] {
+		videos = append(videos, video)
+	}
+	sortByViews(videos)
+
+	return pageVideos(videos, page)
+}
+
+func (r *memoryAnalytics) ListVideoAnalytics(ctx context.Context, videoID gocql.UUID, since time.Time) ([]models.VideoAnalytics, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var analytics []models.VideoAnalytics
+	for _, stat := range r.analytics[videoID] {
+		if !stat.Date.Before(since) {
+			analytics = append(analytics, stat)
+		}
+	}
+	return analytics, nil
+}
+
+// Search
+
+type memorySearch struct {
+	mu    sync.RWMutex
+	index map[string]map[gocql.UUID]models.Video
+}
+
+func (r *memorySearch) Index(ctx context.Context, keyword string, video *models.Video) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, ok := r.index[keyword]
+	if !ok {
+		entries = make(map[gocql.UUID]models.Video)
+		r.index[keyword] = entries
+	}
+	entries[video.ID] = models.Video{
+		ID:           video.ID,
+		Title:        video.Title,
+		ThumbnailURL: video.ThumbnailURL,
+		CreatedAt:    video.CreatedAt,
+	}
+	return nil
+}
+
+func (r *memorySearch) Remove(ctx context.Context, keyword string, createdAt time.Time, videoID gocql.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.index[keyword], videoID)
+	if len(r.index[keyword]) == 0 {
+		delete(r.index, keyword)
+	}
+	return nil
+}
+
+// Search video_search kabi yangi videolar birinchi
+func (r *memorySearch) Search(ctx context.Context, keyword string, page PageRequest) ([]models.Video, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var videos []models.Video
+	for _, video := range r.index[keyword] {
+		videos = append(videos, video)
+	}
+	sortNewestFirst(videos)
+
+	return pageVideos(videos, page)
+}
+
+// Comments
+
+type memoryComments struct {
+	mu       sync.RWMutex
+	comments map[gocql.UUID][]models.Comment
+}
+
+func (r *memoryComments) Add(ctx context.Context, comment *models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := append(r.comments[comment.VideoID], *comment)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].CreatedAt.After(list[j].CreatedAt)
+	})
+	r.comments[comment.VideoID] = list
+	return nil
+}
+
+func (r *memoryComments) List(ctx context.Context, videoID gocql.UUID, limit int) ([]models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := append([]models.Comment(nil), r.comments[videoID]...)
+	if limit > 0 && len(comments) > limit {
+		comments = comments[:limit]
+	}
+	return comments, nil
+}
+
+// Processing jobs
+
+type memoryJobs struct {
+	mu   sync.RWMutex
+	jobs map[gocql.UUID]models.ProcessingJob
+}
+
+func (r *memoryJobs) Save(ctx context.Context, job *models.ProcessingJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.JobID] = *job
+	return nil
+}
+
+func (r *memoryJobs) Get(ctx context.Context, jobID gocql.UUID) (*models.ProcessingJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &job, nil
+}
+
+func (r *memoryJobs) ListByVideo(ctx context.Context, videoID gocql.UUID) ([]models.ProcessingJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []models.ProcessingJob
+	for _, job := range r.jobs {
+		if job.VideoID == videoID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return jobs, nil
+}
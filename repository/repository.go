@@ -0,0 +1,105 @@
+// repository/repository.go
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/gocql/gocql"
+)
+
+var (
+	ErrNotFound         = errors.New("yozuv topilmadi")
+	ErrInvalidPageState = errors.New("sahifa holati noto'g'ri")
+)
+
+// PageRequest sahifalangan o'qish: Size - sahifa hajmi, State - oldingi
+// sahifa qaytargan holat (bo'sh bo'lsa birinchi sahifa). Ro'yxat metodlari
+// keyingi sahifa holatini qaytaradi; oxirgi sahifada u nil.
+type PageRequest struct {
+	Size  int
+	State []byte
+}
+
+// VideoRepository - videos jadvali
+type VideoRepository interface {
+	Create(ctx context.Context, video *models.Video) error
+	Get(ctx context.Context, id gocql.UUID) (*models.Video, error)
+	List(ctx context.Context, page PageRequest) ([]models.Video, []byte, error)
+	MarkReady(ctx context.Context, id gocql.UUID, videoURL string, qualityVersions map[string]string) error
+	SetStatus(ctx context.Context, id gocql.UUID, status string) error
+	MarkFailed(ctx context.Context, id gocql.UUID, errorMessage string) error
+	SetMediaInfo(ctx context.Context, id gocql.UUID, info *models.MediaInfo) error
+	SetAudioVersions(ctx context.Context, id gocql.UUID, versions map[string]string) error
+	SetPreviewTrack(ctx context.Context, id gocql.UUID, trackURL string) error
+	SetThumbnail(ctx context.Context, id gocql.UUID, thumbnailURL string, candidates []models.ThumbnailCandidate) error
+	// SetThumbnailIf asosiy thumbnail hali expectedURL bo'lsagina yozadi (false - o'zgargan)
+	SetThumbnailIf(ctx context.Context, id gocql.UUID, thumbnailURL string, candidates []models.ThumbnailCandidate, expectedURL string) (bool, error)
+	Delete(ctx context.Context, id gocql.UUID) error
+}
+
+// VideoCounterRepository - video_counters jadvali (views, likes, dislikes).
+// Yozuvi yo'q video uchun nol qiymatlar qaytariladi.
+type VideoCounterRepository interface {
+	Get(ctx context.Context, id gocql.UUID) (models.VideoCounters, error)
+	GetMany(ctx context.Context, ids []gocql.UUID) (map[gocql.UUID]models.VideoCounters, error)
+	IncrementViews(ctx context.Context, id gocql.UUID, n int64) error
+	Delete(ctx context.Context, id gocql.UUID) error
+}
+
+// VideosByUserRepository - videos_by_user jadvali (kanal sahifasi uchun)
+type VideosByUserRepository interface {
+	Add(ctx context.Context, video *models.Video) error
+	Remove(ctx context.Context, userID gocql.UUID, createdAt time.Time, videoID gocql.UUID) error
+	// List foydalanuvchi videolarini yangilari birinchi tartibda qaytaradi;
+	// after berilsa shu qatordan keyingilari (clustering tartibida)
+	List(ctx context.Context, userID gocql.UUID, after *UserVideosCursor, limit int) ([]models.Video, error)
+}
+
+// UserVideosCursor videos_by_user dagi oxirgi qaytarilgan qator (clustering key)
+type UserVideosCursor struct {
+	CreatedAt time.Time
+	VideoID   gocql.UUID
+}
+
+// AnalyticsRepository - trending_videos va video_analytics jadvallari
+type AnalyticsRepository interface {
+	// SetTrending time bucketdagi ro'yxatni butunlay almashtiradi (views - snapshot)
+	SetTrending(ctx context.Context, timeBucket string, videos []models.Video) error
+	ListTrending(ctx context.Context, timeBucket string, page PageRequest) ([]models.Video, []byte, error)
+	ListVideoAnalytics(ctx context.Context, videoID gocql.UUID, since time.Time) ([]models.VideoAnalytics, error)
+}
+
+// SearchRepository - video_search jadvali
+type SearchRepository interface {
+	Index(ctx context.Context, keyword string, video *models.Video) error
+	// Remove videoni kalit so'z indeksidan o'chiradi (created_at - clustering kalit qismi)
+	Remove(ctx context.Context, keyword string, createdAt time.Time, videoID gocql.UUID) error
+	Search(ctx context.Context, keyword string, page PageRequest) ([]models.Video, []byte, error)
+}
+
+// CommentRepository - comments jadvali
+type CommentRepository interface {
+	Add(ctx context.Context, comment *models.Comment) error
+	List(ctx context.Context, videoID gocql.UUID, limit int) ([]models.Comment, error)
+}
+
+// JobRepository - processing_jobs va processing_jobs_by_video jadvallari
+type JobRepository interface {
+	Save(ctx context.Context, job *models.ProcessingJob) error
+	Get(ctx context.Context, jobID gocql.UUID) (*models.ProcessingJob, error)
+	ListByVideo(ctx context.Context, videoID gocql.UUID) ([]models.ProcessingJob, error)
+}
+
+// Repositories - barcha repositorylar to'plami
+type Repositories struct {
+	Videos       VideoRepository
+	Counters     VideoCounterRepository
+	VideosByUser VideosByUserRepository
+	Analytics    AnalyticsRepository
+	Search       SearchRepository
+	Comments     CommentRepository
+	Jobs         JobRepository
+}
@@ -0,0 +1,125 @@
+// services/audio.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/Coding-for-Machine/Videos-Service/transcoder"
+	"github.com/gocql/gocql"
+)
+
+// audioFormat - audio-only rendition ("listen mode" uchun)
+type audioFormat struct {
+	Name string // storage va API dagi nomi, masalan "aac-128k"
+	Ext  string
+	Spec transcoder.AudioSpec
+}
+
+// audioFormats - AAC hamma joyda ishlaydi (HLS audio varianti ham shundan),
+// Opus past bitrateda sifatliroq (brauzerlar uchun)
+var audioFormats = []audioFormat{
+	{Name: "aac-128k", Ext: ".m4a", Spec: transcoder.AudioSpec{Codec: "aac", Bitrate: 128, Channels: 2}},
+	{Name: "opus-96k", Ext: ".opus", Spec: transcoder.AudioSpec{Codec: "opus", Bitrate: 96, Channels: 2}},
+}
+
+// audioHLSName HLS audio-only varianti nomi (hls/<nom>/index.m3u8)
+const audioHLSName = "audio"
+
+// ExtractAudio manbadan audio-only renditionlarni (AAC, Opus) va HLS audio
+// variantini yaratadi. Natija: nom -> URL ("hls" - audio playlist).
+// Manbada audio bo'lmasa bo'sh natija qaytaradi.
+func (s *ProcessingService) ExtractAudio(ctx context.Context, videoID gocql.UUID, fileName string) (map[string]string, error) {
+	log.Printf("Audio ajratish boshlandi: %s", videoID)
+
+	ws, inputPath, err := s.openSource(ctx, videoID, fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Release()
+
+	info, err := s.transcoder.Probe(ctx, inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("probe xatosi: %w", err)
+	}
+	if info.Audio == nil {
+		log.Printf("Videoda audio yo'q: %s", videoID)
+		return map[string]string{}, nil
+	}
+
+	workDir, err := ws.TempDir("audio-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	versions := make(map[string]string)
+	for _, format := range audioFormats {
+		spec := format.Spec
+		// Mono manbani stereoga "ko'paytirish" shart emas
+		if info.Audio.Channels > 0 && info.Audio.Channels < spec.Channels {
+			spec.Channels = info.Audio.Channels
+		}
+
+		file := format.Name + format.Ext
+		outputPath := filepath.Join(workDir, file)
+		if err := s.transcoder.ExtractAudio(ctx, inputPath, outputPath, spec); err != nil {
+			log.Printf("Audio xatosi (%s): %v", format.Name, err)
+			continue
+		}
+
+		asset := assets.Of(videoID, assets.Audio, file)
+		if err := s.uploadAsset(ctx, asset, outputPath); err != nil {
+			log.Printf("Audio yuklanmadi (%s): %v", format.Name, err)
+			continue
+		}
+		versions[format.Name] = asset.URL()
+
+		// HLS audio-only varianti AAC fayldan qayta kodlamasdan
+		if s.cfg.HLSEnabled && spec.Codec == "aac" {
+			if err := s.packageHLSAudio(ctx, videoID, outputPath, workDir); err != nil {
+				log.Printf("HLS audio xatosi: %v", err)
+			} else {
+				versions["hls"] = assets.Of(videoID, assets.HLS, audioHLSName+"/index.m3u8").URL()
+			}
+		}
+	}
+
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("hech bir audio format tayyorlanmadi")
+	}
+
+	log.Printf("Audio ajratish tugadi: %s", videoID)
+	return versions, nil
+}
+
+// packageHLSAudio AAC faylni HLS segmentlariga bo'ladi va master playlistni
+// audio-only varianti bilan qayta yozadi (transcode hali tugamagan bo'lsa
+// master'ni keyinroq transcode job yozadi)
+func (s *ProcessingService) packageHLSAudio(ctx context.Context, videoID gocql.UUID, inputPath, workDir string) error {
+	outDir, err := os.MkdirTemp(workDir, "hls-audio-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outDir)
+
+	if err := s.transcoder.PackageHLS(ctx, inputPath, outDir, s.cfg.HLSSegmentTime); err != nil {
+		return err
+	}
+	if err := s.uploadDir(ctx, s.buckets.Processed, path.Join(assets.Prefix(videoID, assets.HLS), audioHLSName), outDir); err != nil {
+		return err
+	}
+
+	return s.syncMasterPlaylist(ctx, videoID)
+}
+
+// audioVariant master playlistdagi audio-only varianti (AAC 128k)
+func audioVariant() string {
+	return fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"mp4a.40.2\",NAME=\"%s\"\nhls/%s/index.m3u8\n",
+		audioFormats[0].Spec.Bitrate*1000, audioHLSName, audioHLSName)
+}
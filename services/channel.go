@@ -0,0 +1,94 @@
+// services/channel.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
+	"github.com/gocql/gocql"
+)
+
+var ErrInvalidUserID = errors.New("noto'g'ri user ID")
+
+// ListUserVideos foydalanuvchi (kanal) videolarini yangilari birinchi tartibda
+// qaytaradi. Keyingi sahifa uchun cursor qaytariladi (oxirgi sahifada bo'sh).
+func (s *VideoService) ListUserVideos(ctx context.Context, userID, cursor string, limit int) ([]models.Video, string, error) {
+	owner, err := gocql.ParseUUID(userID)
+	if err != nil {
+		return nil, "", ErrInvalidUserID
+	}
+
+	var after *repository.UserVideosCursor
+	if cursor != "" {
+		if after, err = decodeUserVideosCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	videos, err := s.repos.VideosByUser.List(ctx, owner, after, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	applyCounters(ctx, s.repos.Counters, videos)
+	for i := range videos {
+		rewriteLegacyURLs(&videos[i])
+	}
+
+	next := ""
+	if len(videos) == limit {
+		last := videos[len(videos)-1]
+		next = encodeUserVideosCursor(repository.UserVideosCursor{CreatedAt: last.CreatedAt, VideoID: last.ID})
+	}
+	return videos, next, nil
+}
+
+// syncChannelEntry videos_by_user dagi denormalizatsiya qilingan nusxani
+// (sarlavha, thumbnail) videos jadvali bilan tenglashtiradi
+func (s *VideoService) syncChannelEntry(ctx context.Context, video *models.Video) {
+	// Yuklanmagan video kanal ro'yxatiga faqat MarkUploaded da kiradi
+	if video.Status == "uploading" {
+		return
+	}
+	if err := s.repos.VideosByUser.Add(ctx, video); err != nil {
+		log.Printf("Kanal ro'yxati yangilanmadi (%s): %v", video.ID, err)
+	}
+}
+
+// Kanal kursori PageState emas, oxirgi qatorning clustering kaliti:
+// "<created_at unix nano>:<video_id>". Klient uchun farqi yo'q (ikkalasi ham
+// shaffof base64url satr), lekin videos_by_user da kalit bo'yicha davom
+// ettirish mumkin: bunday kursor driver/protokol versiyasiga bog'liq emas va
+// sahifalar orasida sarlavha yoki thumbnail yangilansa ham qatorlar
+// takrorlanmaydi. Avval berilgan kanal kursorlari ham ishlashda davom etadi.
+func encodeUserVideosCursor(c repository.UserVideosCursor) string {
+	return encodeCursor([]byte(fmt.Sprintf("%d:%s", c.CreatedAt.UnixNano(), c.VideoID)))
+}
+
+func decodeUserVideosCursor(cursor string) (*repository.UserVideosCursor, error) {
+	raw, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	videoID, err := gocql.ParseUUID(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &repository.UserVideosCursor{CreatedAt: time.Unix(0, n), VideoID: videoID}, nil
+}
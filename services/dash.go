@@ -0,0 +1,26 @@
+// services/dash.go
+package services
+
+import (
+	"context"
+	"os"
+
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/gocql/gocql"
+)
+
+// packageDASH tayyor MP4 sifatlarni bitta MPD manifest ostida fMP4 segmentlarga bo'ladi.
+// Audio (manbada bo'lsa) eng yuqori sifatdan olinadi.
+func (s *ProcessingService) packageDASH(ctx context.Context, videoID gocql.UUID, inputs []string, hasAudio bool, workDir string) error {
+	outDir, err := os.MkdirTemp(workDir, "dash-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outDir)
+
+	if err := s.transcoder.PackageDASH(ctx, inputs, hasAudio, outDir, s.cfg.HLSSegmentTime); err != nil {
+		return err
+	}
+
+	return s.uploadDir(ctx, s.buckets.Processed, assets.Prefix(videoID, assets.DASH), outDir)
+}
@@ -0,0 +1,194 @@
+// services/hls.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+	"github.com/gocql/gocql"
+)
+
+// packageHLSRendition tayyor MP4 ni segmentlarga bo'lib, media playlist bilan storagega yuklaydi
+func (s *ProcessingService) packageHLSRendition(ctx context.Context, videoID gocql.UUID, r rendition, inputPath, workDir string) error {
+	outDir, err := os.MkdirTemp(workDir, fmt.Sprintf("hls-%s-", r.Name))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outDir)
+
+	if err := s.transcoder.PackageHLS(ctx, inputPath, outDir, s.cfg.HLSSegmentTime); err != nil {
+		return err
+	}
+
+	return s.uploadDir(ctx, s.buckets.Processed, path.Join(assets.Prefix(videoID, assets.HLS), r.Name), outDir)
+}
+
+// hlsVariant - master playlistdagi bitta video varianti
+type hlsVariant struct {
+	Name      string `json:"name"`
+	Bandwidth int    `json:"bandwidth"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Codecs    string `json:"codecs"`
+}
+
+// masterState - master.m3u8 qayta yoziladigan saqlangan holat: transcode job
+// yozgan video variantlari (variants.json) va extract_audio job yozgan audio playlist
+type masterState struct {
+	variants string // variants.json tarkibi ("" - transcode hali tugamagan)
+	audio    bool
+}
+
+// uploadMasterPlaylist transcode jobning video variantlarini saqlaydi va
+// master playlistni qayta yozadi
+func (s *ProcessingService) uploadMasterPlaylist(ctx context.Context, videoID gocql.UUID, packaged []rendition) error {
+	variants := make([]hlsVariant, len(packaged))
+	for i, r := range packaged {
+		variants[i] = hlsVariant{Name: r.Name, Bandwidth: r.Bandwidth(), Width: r.Width, Height: r.Height, Codecs: r.Codecs()}
+	}
+	data, err := json.Marshal(variants)
+	if err != nil {
+		return err
+	}
+
+	asset := assets.Of(videoID, assets.HLS, "variants.json")
+	if err := s.store.Put(ctx, asset.Bucket(s.buckets), asset.Key(), bytes.NewReader(data), int64(len(data)), "application/json"); err != nil {
+		return err
+	}
+
+	return s.syncMasterPlaylist(ctx, videoID)
+}
+
+// syncMasterPlaylist master.m3u8 ni saqlangan holatdan qayta yozadi. Transcode
+// va extract_audio joblari parallel ishlaydi va har biri o'z qismini
+// saqlagandan keyin shu funksiyani chaqiradi. Master yozilgandan keyin holat
+// qayta o'qiladi: shu orada ikkinchi job tugagan bo'lsa master qayta yoziladi,
+// shuning uchun oxirgi yozuv doim ikkala jobning natijasini o'z ichiga oladi.
+func (s *ProcessingService) syncMasterPlaylist(ctx context.Context, videoID gocql.UUID) error {
+	state, err := s.masterState(ctx, videoID)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		// Video variantlarsiz master yo'q: audio job transcode'dan oldin tugasa
+		// uni transcode job qo'shadi
+		if state.variants == "" {
+			return nil
+		}
+
+		var variants []hlsVariant
+		if err := json.Unmarshal([]byte(state.variants), &variants); err != nil {
+			return err
+		}
+		if err := s.writeMasterPlaylist(ctx, videoID, variants, state.audio); err != nil {
+			return err
+		}
+
+		current, err := s.masterState(ctx, videoID)
+		if err != nil {
+			return err
+		}
+		if current == state {
+			return nil
+		}
+		state = current
+	}
+
+	return fmt.Errorf("master playlist holati barqarorlashmadi: %s", videoID)
+}
+
+// masterState variants.json va audio playlist mavjudligini o'qiydi
+func (s *ProcessingService) masterState(ctx context.Context, videoID gocql.UUID) (masterState, error) {
+	var state masterState
+
+	variants := assets.Of(videoID, assets.HLS, "variants.json")
+	object, err := s.store.Get(ctx, variants.Bucket(s.buckets), variants.Key())
+	switch {
+	case err == nil:
+		data, err := io.ReadAll(object)
+		object.Close()
+		if err != nil {
+			return state, err
+		}
+		state.variants = string(data)
+	case !errors.Is(err, storage.ErrNotFound):
+		return state, err
+	}
+
+	audioPlaylist := assets.Of(videoID, assets.HLS, audioHLSName+"/index.m3u8")
+	_, err = s.store.Stat(ctx, audioPlaylist.Bucket(s.buckets), audioPlaylist.Key())
+	switch {
+	case err == nil:
+		state.audio = true
+	case !errors.Is(err, storage.ErrNotFound):
+		return state, err
+	}
+
+	return state, nil
+}
+
+// writeMasterPlaylist barcha sifatlar (va bo'lsa audio-only varianti) uchun master playlist yozadi
+func (s *ProcessingService) writeMasterPlaylist(ctx context.Context, videoID gocql.UUID, variants []hlsVariant, audio bool) error {
+	var buf bytes.Buffer
+	buf.WriteString("#EXTM3U\n")
+	buf.WriteString("#EXT-X-VERSION:3\n")
+	buf.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+
+	for _, v := range variants {
+		fmt.Fprintf(&buf, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=\"%s\",NAME=\"%s\"\n",
+			v.Bandwidth, v.Width, v.Height, v.Codecs, v.Name)
+		// Nisbiy URL - player master.m3u8 manzilidan hisoblaydi
+		fmt.Fprintf(&buf, "hls/%s/index.m3u8\n", v.Name)
+	}
+
+	// Audio-only varianti (extract_audio job tayyorlagan bo'lsa)
+	if audio {
+		buf.WriteString(audioVariant())
+	}
+
+	master := assets.Of(videoID, assets.HLS, "master.m3u8")
+	return s.store.Put(ctx, master.Bucket(s.buckets), master.Key(), &buf, int64(buf.Len()), master.ContentType())
+}
+
+// uploadDir papkadagi barcha fayllarni prefix ostida storagega yuklaydi
+func (s *ProcessingService) uploadDir(ctx context.Context, bucket, prefix, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		file, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		fileInfo, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		objectName := path.Join(prefix, entry.Name())
+		err = s.store.Put(ctx, bucket, objectName, file, fileInfo.Size(), assets.ContentTypeFor(entry.Name()))
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("storagega yuklash xatosi (%s): %w", objectName, err)
+		}
+	}
+
+	return nil
+}
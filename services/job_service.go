@@ -0,0 +1,68 @@
+// services/job_service.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/queue"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
+	"github.com/gocql/gocql"
+)
+
+// JobService processing joblarning holatini Cassandrada saqlaydi
+type JobService struct {
+	repos repository.Repositories
+}
+
+// PoolMetrics - job turi bo'yicha processing pool metrikalari
+type PoolMetrics struct {
+	JobType     string      `json:"job_type"`
+	Concurrency int         `json:"concurrency"`
+	InFlight    int64       `json:"in_flight"` // shu replikada ishlanayotgan
+	Completed   int64       `json:"completed"` // shu replika ishga tushgandan beri
+	Failed      int64       `json:"failed"`
+	Queue       queue.Stats `json:"queue"` // barcha replikalar bo'yicha
+}
+
+// PoolMetricsProvider processing pool metrikalarini beradi
+// (workers.ProcessingPool). Handlerlar workers paketiga bog'lanmaydi.
+type PoolMetricsProvider interface {
+	Metrics(ctx context.Context) ([]PoolMetrics, error)
+}
+
+func NewJobService(repos repository.Repositories) *JobService {
+	return &JobService{repos: repos}
+}
+
+// Transition job holatini o'zgartiradi va yozadi. Yozish xatosi jobni
+// to'xtatmaydi - faqat logga chiqariladi.
+func (s *JobService) Transition(ctx context.Context, job *models.ProcessingJob, status string) {
+	job.Status = status
+	job.UpdatedAt = time.Now()
+
+	if err := s.repos.Jobs.Save(ctx, job); err != nil {
+		log.Printf("Job holati saqlanmadi: %s (%s): %v", job.JobID, status, err)
+	}
+}
+
+func (s *JobService) GetJob(ctx context.Context, jobID string) (*models.ProcessingJob, error) {
+	id, err := gocql.ParseUUID(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("noto'g'ri job ID: %w", err)
+	}
+
+	return s.repos.Jobs.Get(ctx, id)
+}
+
+func (s *JobService) GetVideoJobs(ctx context.Context, videoID string) ([]models.ProcessingJob, error) {
+	id, err := gocql.ParseUUID(videoID)
+	if err != nil {
+		return nil, fmt.Errorf("noto'g'ri video ID: %w", err)
+	}
+
+	return s.repos.Jobs.ListByVideo(ctx, id)
+}
@@ -0,0 +1,157 @@
+// services/ladder.go
+package services
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+)
+
+// ladderRung - sifat pog'onasi. Pog'ona kadrning qisqa tomoni bo'yicha
+// aniqlanadi, shuning uchun vertikal videolar ham bir xil nomlanadi.
+type ladderRung struct {
+	ShortSide    int
+	VideoBitrate int // kbps, 30 fps gacha
+	AudioBitrate int // kbps
+}
+
+// Pog'onalar (pastdan yuqoriga)
+var ladderRungs = []ladderRung{
+	{ShortSide: 360, VideoBitrate: 800, AudioBitrate: 96},
+	{ShortSide: 480, VideoBitrate: 1400, AudioBitrate: 128},
+	{ShortSide: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{ShortSide: 1080, VideoBitrate: 5000, AudioBitrate: 192},
+}
+
+// maxFrameRate - bundan yuqori fps pasaytiriladi
+const maxFrameRate = 60
+
+// buildLadder manba faylga mos rendition ro'yxatini tuzadi:
+//   - hech qachon upscale qilinmaydi (manbadan katta pog'onalar tashlanadi);
+//   - aspect ratio saqlanadi, vertikal/rotated videolar uchun kenglik va
+//     balandlik almashadi;
+//   - bitrate pog'ona chegarasidan va manba bitratesidan oshmaydi.
+//
+// info nil bo'lsa (ffprobe ishlamadi) 16:9 landscape deb hisoblanadi.
+func buildLadder(info *models.MediaInfo) []rendition {
+	srcWidth, srcHeight := 1920, 1080
+	frameRate := 30.0
+	hasAudio := true
+	var srcVideoKbps, srcAudioKbps int
+
+	if info != nil && info.Video != nil {
+		srcWidth, srcHeight = info.Video.DisplaySize()
+		frameRate = info.Video.FrameRate
+		srcVideoKbps = int(info.Video.Bitrate / 1000)
+		if srcVideoKbps == 0 {
+			// Ba'zi konteynerlar oqim bitratesini bermaydi - umumiy bitrate yuqori chegara
+			srcVideoKbps = int(info.Bitrate / 1000)
+		}
+
+		hasAudio = info.Audio != nil
+		if hasAudio {
+			srcAudioKbps = int(info.Audio.Bitrate / 1000)
+		}
+	}
+
+	var fpsLimit float64
+	if frameRate <= 0 {
+		frameRate = 30
+	}
+	if frameRate > maxFrameRate {
+		frameRate = maxFrameRate
+		fpsLimit = maxFrameRate
+	}
+
+	shortSide := min(srcWidth, srcHeight)
+	if shortSide <= 0 {
+		srcWidth, srcHeight, shortSide = 1920, 1080, 1080
+	}
+
+	var ladder []rendition
+	for _, rung := range ladderRungs {
+		if rung.ShortSide > shortSide {
+			break
+		}
+		ladder = append(ladder, newRendition(rung, rung.ShortSide, srcWidth, srcHeight, frameRate, fpsLimit, hasAudio, srcVideoKbps, srcAudioKbps))
+	}
+
+	// Manba eng kichik pog'onadan ham kichik - o'z o'lchamida bitta rendition
+	if len(ladder) == 0 {
+		ladder = append(ladder, newRendition(ladderRungs[0], shortSide, srcWidth, srcHeight, frameRate, fpsLimit, hasAudio, srcVideoKbps, srcAudioKbps))
+	}
+
+	return ladder
+}
+
+func newRendition(rung ladderRung, target, srcWidth, srcHeight int, frameRate, fpsLimit float64, hasAudio bool, srcVideoKbps, srcAudioKbps int) rendition {
+	short := evenDown(target)
+	long := evenDown(int(math.Round(float64(target) * float64(max(srcWidth, srcHeight)) / float64(min(srcWidth, srcHeight)))))
+
+	width, height := long, short
+	if srcHeight > srcWidth {
+		width, height = short, long
+	}
+
+	videoBitrate := rung.VideoBitrate
+	if frameRate > 30 {
+		videoBitrate = videoBitrate * 3 / 2
+	}
+	if srcVideoKbps > 0 && videoBitrate > srcVideoKbps {
+		videoBitrate = srcVideoKbps
+	}
+
+	audioBitrate := rung.AudioBitrate
+	if !hasAudio {
+		audioBitrate = 0
+	} else if srcAudioKbps > 0 && audioBitrate > srcAudioKbps {
+		audioBitrate = srcAudioKbps
+	}
+
+	return rendition{
+		Name:         fmt.Sprintf("%dp", target),
+		Width:        width,
+		Height:       height,
+		FrameRate:    frameRate,
+		FPSLimit:     fpsLimit,
+		VideoBitrate: videoBitrate,
+		AudioBitrate: audioBitrate,
+		Level:        h264Level(width, height, frameRate),
+	}
+}
+
+// evenDown libx264 (yuv420p) juft o'lcham talab qiladi
+func evenDown(n int) int {
+	if n < 2 {
+		return 2
+	}
+	return n - n%2
+}
+
+// h264Level kadr o'lchami va fps uchun yetarli eng past H.264 level
+// (ITU-T H.264 Table A-1: MaxFS va MaxMBPS)
+func h264Level(width, height int, frameRate float64) string {
+	frameSize := ((width + 15) / 16) * ((height + 15) / 16) // macroblocklar
+	mbps := float64(frameSize) * frameRate
+
+	levels := []struct {
+		name    string
+		maxFS   int
+		maxMBPS float64
+	}{
+		{"3.0", 1620, 40500},
+		{"3.1", 3600, 108000},
+		{"3.2", 5120, 216000},
+		{"4.0", 8192, 245760},
+		{"4.2", 8704, 522240},
+		{"5.1", 36864, 983040},
+	}
+
+	for _, level := range levels {
+		if frameSize <= level.maxFS && mbps <= level.maxMBPS {
+			return level.name
+		}
+	}
+	return "5.1"
+}
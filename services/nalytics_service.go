@@ -4,18 +4,24 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
 	"github.com/gocql/gocql"
 )
 
+// trendingBucketLayout trending_videos dagi soatlik time bucket formati
+const trendingBucketLayout = "2006-01-02-15"
+
 type AnalyticsService struct {
-	cassandra *gocql.Session
+	repos repository.Repositories
 }
 
-func NewAnalyticsService(cassandra *gocql.Session) *AnalyticsService {
-	return &AnalyticsService{cassandra: cassandra}
+func NewAnalyticsService(repos repository.Repositories) *AnalyticsService {
+	return &AnalyticsService{repos: repos}
 }
 
 // Soatlik statistikani to'plash
@@ -35,73 +41,59 @@ func (s *AnalyticsService) AggregateHourlyStats(ctx context.Context) error {
 // Trending videolarni yangilash
 func (s *AnalyticsService) UpdateTrendingVideos(ctx context.Context) error {
 	now := time.Now()
-	timeBucket := now.Format("2006-01-02-15") // Soatlik bucket
+	timeBucket := now.Format(trendingBucketLayout)
 
 	// Oxirgi 24 soat ichidagi eng ko'p ko'rilgan videolarni olish
-	query := `SELECT id, title, thumbnail_url FROM videos LIMIT 100`
-	iter := s.cassandra.Query(query).Iter()
-
-	type VideoWithViews struct {
-		ID           gocql.UUID
-		Title        string
-		ThumbnailURL string
-		Views        int64
-	}
-
-	var videos []VideoWithViews
-	var video VideoWithViews
-
-	for iter.Scan(&video.ID, &video.Title, &video.ThumbnailURL) {
-		// Views counterini olish
-		viewQuery := "SELECT views FROM videos WHERE id = ?"
-		s.cassandra.Query(viewQuery, video.ID).Scan(&video.Views)
-
-		videos = append(videos, video)
-		video = VideoWithViews{}
-	}
-
-	if err := iter.Close(); err != nil {
+	videos, _, err := s.repos.Videos.List(ctx, repository.PageRequest{Size: 100})
+	if err != nil {
 		return err
 	}
 
-	// Trending jadvaliga yozish
-	for _, v := range videos {
-		insertQuery := `INSERT INTO trending_videos (time_bucket, video_id, title, 
-			thumbnail_url, created_at) VALUES (?, ?, ?, ?, ?)`
-		s.cassandra.Query(insertQuery, timeBucket, v.ID, v.Title,
-			v.ThumbnailURL, time.Now()).Exec()
-
-		// Counter update
-		updateQuery := `UPDATE trending_videos SET views = views + ? 
-			WHERE time_bucket = ? AND video_id = ?`
-		s.cassandra.Query(updateQuery, v.Views, timeBucket, v.ID).Exec()
+	applyCounters(ctx, s.repos.Counters, videos)
+	for i := range videos {
+		rewriteLegacyURLs(&videos[i])
 	}
+	sort.SliceStable(videos, func(i, j int) bool {
+		return videos[i].Views > videos[j].Views
+	})
 
-	return nil
+	// Trending jadvalini yangilash (views - shu paytdagi snapshot)
+	return s.repos.Analytics.SetTrending(ctx, timeBucket, videos)
 }
 
-// Trending videolarni olish
-func (s *AnalyticsService) GetTrendingVideos(ctx context.Context, limit int) ([]models.Video, error) {
-	now := time.Now()
-	timeBucket := now.Format("2006-01-02-15")
-
-	query := `SELECT video_id, title, thumbnail_url, created_at 
-		FROM trending_videos WHERE time_bucket = ? LIMIT ?`
-	iter := s.cassandra.Query(query, timeBucket, limit).Iter()
-
-	var videos []models.Video
-	var video models.Video
+// Trending videolarni olish. Kursor time bucketni ham saqlaydi: soat
+// almashsa ham klient boshlagan snapshot oxirigacha varaqlanadi.
+func (s *AnalyticsService) GetTrendingVideos(ctx context.Context, cursor string, limit int) ([]models.Video, string, error) {
+	timeBucket := time.Now().Format(trendingBucketLayout)
+
+	var state []byte
+	if cursor != "" {
+		raw, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		bucket, pageState, ok := strings.Cut(string(raw), "|")
+		if _, err := time.Parse(trendingBucketLayout, bucket); !ok || err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		timeBucket, state = bucket, []byte(pageState)
+	}
 
-	for iter.Scan(&video.ID, &video.Title, &video.ThumbnailURL, &video.CreatedAt) {
-		// Views counterini olish
-		viewQuery := "SELECT views FROM trending_videos WHERE time_bucket = ? AND video_id = ?"
-		s.cassandra.Query(viewQuery, timeBucket, video.ID).Scan(&video.Views)
+	videos, next, err := s.repos.Analytics.ListTrending(ctx, timeBucket, repository.PageRequest{Size: limit, State: state})
+	if err != nil {
+		return nil, "", pageError(err)
+	}
 
-		videos = append(videos, video)
-		video = models.Video{}
+	// Tartib snapshot bo'yicha, ko'rsatiladigan sonlar esa joriy
+	applyCounters(ctx, s.repos.Counters, videos)
+	for i := range videos {
+		rewriteLegacyURLs(&videos[i])
 	}
 
-	return videos, iter.Close()
+	if next == nil {
+		return videos, "", nil
+	}
+	return videos, encodeCursor(append([]byte(timeBucket+"|"), next...)), nil
 }
 
 // Video uchun analytics
@@ -113,18 +105,5 @@ func (s *AnalyticsService) GetVideoAnalytics(ctx context.Context, videoID string
 
 	startDate := time.Now().AddDate(0, 0, -days)
 
-	query := `SELECT video_id, date, hour, views, watch_time, likes, shares 
-		FROM video_analytics WHERE video_id = ? AND date >= ?`
-	iter := s.cassandra.Query(query, id, startDate).Iter()
-
-	var analytics []models.VideoAnalytics
-	var stat models.VideoAnalytics
-
-	for iter.Scan(&stat.VideoID, &stat.Date, &stat.Hour,
-		&stat.Views, &stat.WatchTime, &stat.Likes, &stat.Shares) {
-		analytics = append(analytics, stat)
-		stat = models.VideoAnalytics{}
-	}
-
-	return analytics, iter.Close()
+	return s.repos.Analytics.ListVideoAnalytics(ctx, id, startDate)
 }
@@ -0,0 +1,34 @@
+// services/pagination.go
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/Coding-for-Machine/Videos-Service/repository"
+)
+
+// ErrInvalidCursor klient yuborgan sahifa kursori noto'g'ri
+var ErrInvalidCursor = errors.New("noto'g'ri cursor")
+
+// Kursor klient uchun shaffof emas: repository sahifa holatining base64url
+// ko'rinishi. Oxirgi sahifada bo'sh satr.
+func encodeCursor(state []byte) string {
+	return base64.RawURLEncoding.EncodeToString(state)
+}
+
+func decodeCursor(cursor string) ([]byte, error) {
+	state, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return state, nil
+}
+
+// pageError repository sahifa xatosini klient xatosiga aylantiradi
+func pageError(err error) error {
+	if errors.Is(err, repository.ErrInvalidPageState) {
+		return ErrInvalidCursor
+	}
+	return err
+}
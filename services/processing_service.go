@@ -7,196 +7,293 @@ import (
 	"io"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
-
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+	"github.com/Coding-for-Machine/Videos-Service/transcoder"
+	"github.com/Coding-for-Machine/Videos-Service/workspace"
 	"github.com/gocql/gocql"
-	"github.com/minio/minio-go/v7"
 )
 
 type ProcessingService struct {
-	minio *minio.Client
+	transcoder transcoder.Transcoder
+	store      storage.ObjectStore
+	buckets    config.Buckets
+	progress   *ProgressService
+	workspaces *workspace.Manager
+	cfg        config.ProcessingConfig
 }
 
-func NewProcessingService(minio *minio.Client) *ProcessingService {
-	return &ProcessingService{minio: minio}
+func NewProcessingService(tc transcoder.Transcoder, store storage.ObjectStore, buckets config.Buckets, progress *ProgressService, workspaces *workspace.Manager, cfg config.ProcessingConfig) *ProcessingService {
+	return &ProcessingService{
+		transcoder: tc,
+		store:      store,
+		buckets:    buckets,
+		progress:   progress,
+		workspaces: workspaces,
+		cfg:        cfg,
+	}
 }
 
-// Video transcoding - turli sifatda
-func (s *ProcessingService) TranscodeVideo(ctx context.Context, videoID gocql.UUID, fileName string) (map[string]string, error) {
-	log.Printf("Video transcoding boshlandi: %s", videoID)
+// openSource videoning workspace'ini oladi va raw faylni unga yuklab oladi.
+// Bir vaqtda ishlayotgan joblar bitta nusxani bo'lishadi. Chaqiruvchi
+// workspace'ni Release qilishi kerak.
+func (s *ProcessingService) openSource(ctx context.Context, videoID gocql.UUID, fileName string) (*workspace.Workspace, string, error) {
+	ws, err := s.workspaces.Acquire(videoID.String())
+	if err != nil {
+		return nil, "", err
+	}
 
-	// MinIOdan raw videoni yuklab olish
-	objectName := fmt.Sprintf("raw/%s-%s", videoID.String(), fileName)
-	object, err := s.minio.GetObject(ctx, "videos-raw", objectName, minio.GetObjectOptions{})
+	objectName := RawObjectName(videoID, fileName)
+	info, err := s.store.Stat(ctx, s.buckets.Raw, objectName)
 	if err != nil {
-		return nil, fmt.Errorf("MinIOdan yuklash xatosi: %w", err)
+		ws.Release()
+		return nil, "", fmt.Errorf("storagedan yuklash xatosi: %w", err)
 	}
-	defer object.Close()
 
-	// Vaqtinchalik faylga saqlash
-	tempDir := os.TempDir()
-	inputPath := filepath.Join(tempDir, fmt.Sprintf("%s-input.mp4", videoID))
-	outputFile, err := os.Create(inputPath)
+	inputPath, err := ws.Source(ctx, info.Size, func(ctx context.Context, dst io.Writer) error {
+		object, err := s.store.Get(ctx, s.buckets.Raw, objectName)
+		if err != nil {
+			return fmt.Errorf("storagedan yuklash xatosi: %w", err)
+		}
+		defer object.Close()
+
+		_, err = io.Copy(dst, object)
+		return err
+	})
+	if err != nil {
+		ws.Release()
+		return nil, "", err
+	}
+
+	return ws, inputPath, nil
+}
+
+// rendition - bitta sifat varianti
+type rendition struct {
+	Name         string
+	Width        int
+	Height       int
+	FrameRate    float64
+	FPSLimit     float64 // 0 - manba fps saqlanadi
+	VideoBitrate int     // kbps (maxrate)
+	AudioBitrate int     // kbps, 0 - audio yo'q
+	Level        string  // H.264 level
+}
+
+// Codecs HLS/DASH uchun RFC 6381 codec satri (H.264 Main + AAC-LC)
+func (r rendition) Codecs() string {
+	level := map[string]string{
+		"3.0": "1e", "3.1": "1f", "3.2": "20", "4.0": "28", "4.1": "29", "4.2": "2a", "5.1": "33",
+	}[r.Level]
+
+	if r.AudioBitrate == 0 {
+		return fmt.Sprintf("avc1.4d40%s", level)
+	}
+	return fmt.Sprintf("avc1.4d40%s,mp4a.40.2", level)
+}
+
+// Bandwidth bitrate (bit/s) - video va audio yig'indisi
+func (r rendition) Bandwidth() int {
+	return (r.VideoBitrate + r.AudioBitrate) * 1000
+}
+
+// spec transcoder uchun kodlash parametrlari
+func (r rendition) spec(keyframeInterval int) transcoder.RenditionSpec {
+	return transcoder.RenditionSpec{
+		Width:            r.Width,
+		Height:           r.Height,
+		FPSLimit:         r.FPSLimit,
+		VideoBitrate:     r.VideoBitrate,
+		AudioBitrate:     r.AudioBitrate,
+		Level:            r.Level,
+		KeyframeInterval: keyframeInterval,
+	}
+}
+
+// TranscodeResult - transcoding natijasi
+type TranscodeResult struct {
+	QualityVersions map[string]string
+	Qualities       []string // tayyor sifatlar, pastdan yuqoriga
+}
+
+// DefaultURL standart sifat: 720p, bo'lmasa eng yuqori tayyor sifat
+func (r *TranscodeResult) DefaultURL() string {
+	if url, ok := r.QualityVersions["720p"]; ok {
+		return url
+	}
+	if len(r.Qualities) == 0 {
+		return ""
+	}
+	return r.QualityVersions[r.Qualities[len(r.Qualities)-1]]
+}
+
+// Video transcoding - turli sifatda
+func (s *ProcessingService) TranscodeVideo(ctx context.Context, videoID gocql.UUID, fileName string) (result *TranscodeResult, err error) {
+	log.Printf("Video transcoding boshlandi: %s", videoID)
+
+	// Har qanday xatoda SSE obunachilari "failed" ni oladi, aks holda ular
+	// "done" ni kutib qoladi. Job bekor qilingan bo'lsa ham yuborilishi kerak.
+	defer func() {
+		if err != nil {
+			s.publishProgress(context.WithoutCancel(ctx), Progress{VideoID: videoID, Stage: "failed", Error: err.Error()})
+		}
+	}()
+
+	// Raw videoni workspace'ga yuklab olish
+	ws, inputPath, err := s.openSource(ctx, videoID, fileName)
 	if err != nil {
 		return nil, err
 	}
+	defer ws.Release()
 
-	_, err = io.Copy(outputFile, object)
-	outputFile.Close()
+	// Renditionlar, HLS va DASH nusxalari uchun taxminan manbaning 3 barobari
+	if sourceInfo, err := os.Stat(inputPath); err == nil {
+		if err := s.workspaces.CheckSpace(3 * sourceInfo.Size()); err != nil {
+			return nil, err
+		}
+	}
+
+	workDir, err := ws.TempDir("transcode-")
 	if err != nil {
 		return nil, err
 	}
-	defer os.Remove(inputPath)
+	defer os.RemoveAll(workDir)
 
-	// Turli sifatlarda transcode qilish
-	qualities := map[string]string{
-		"360p":  "640x360",
-		"480p":  "854x480",
-		"720p":  "1280x720",
-		"1080p": "1920x1080",
+	// Manbani tekshirish: ladder va progress foizi shunga asoslanadi
+	var duration time.Duration
+	info, err := s.transcoder.Probe(ctx, inputPath)
+	if err != nil {
+		log.Printf("ffprobe xatosi (%s): %v", videoID, err)
+		info = nil
+	} else {
+		duration = time.Duration(info.Duration * float64(time.Second))
 	}
 
+	renditions := buildLadder(info)
+
 	qualityVersions := make(map[string]string)
+	var qualities []string
+	var packaged []rendition
+	var dashInputs []string
 
-	for quality, resolution := range qualities {
-		outputPath := filepath.Join(tempDir, fmt.Sprintf("%s-%s.mp4", videoID, quality))
-
-		// FFmpeg command
-		cmd := exec.Command("ffmpeg",
-			"-i", inputPath,
-			"-vf", fmt.Sprintf("scale=%s", resolution),
-			"-c:v", "libx264",
-			"-crf", "23",
-			"-preset", "medium",
-			"-c:a", "aac",
-			"-b:a", "128k",
-			"-movflags", "+faststart",
-			outputPath,
-		)
-
-		output, err := cmd.CombinedOutput()
+	// Turli sifatlarda transcode qilish
+	for i, r := range renditions {
+		outputPath := filepath.Join(workDir, r.Name+".mp4")
+
+		err := s.transcoder.TranscodeRendition(ctx, inputPath, outputPath, r.spec(s.cfg.HLSSegmentTime), func(p transcoder.Progress) {
+			percent, eta := renditionProgress(p, duration)
+			s.publishProgress(ctx, Progress{
+				VideoID:        videoID,
+				Stage:          "transcode",
+				Rendition:      r.Name,
+				RenditionIndex: i + 1,
+				RenditionCount: len(renditions),
+				Percent:        percent,
+				OverallPercent: (float64(i) + percent/100) / float64(len(renditions)) * 100,
+				ETASeconds:     int(eta.Seconds()),
+				Speed:          p.Speed,
+			})
+		})
 		if err != nil {
-			log.Printf("FFmpeg xatosi (%s): %s", quality, output)
+			log.Printf("Transcode xatosi (%s): %v", r.Name, err)
 			continue
 		}
 
-		// Processed videoni MinIOga yuklash
-		minioPath := fmt.Sprintf("processed/%s/%s-%s.mp4", videoID, videoID, quality)
-		file, _ := os.Open(outputPath)
-		fileInfo, _ := file.Stat()
+		// HLS segmentlash (qayta encode qilmasdan)
+		if s.cfg.HLSEnabled {
+			if err := s.packageHLSRendition(ctx, videoID, r, outputPath, workDir); err != nil {
+				log.Printf("HLS xatosi (%s): %v", r.Name, err)
+			} else {
+				packaged = append(packaged, r)
+			}
+		}
 
-		_, err = s.minio.PutObject(ctx, "videos-processed", minioPath, file, fileInfo.Size(), minio.PutObjectOptions{
-			ContentType: "video/mp4",
-		})
-		file.Close()
-		os.Remove(outputPath)
+		// Processed videoni storagega yuklash
+		asset := assets.Rendition(videoID, r.Name)
+		err = s.uploadAsset(ctx, asset, outputPath)
+
+		// DASH uchun fayl keyinroq kerak bo'ladi (workDir bilan birga o'chiriladi)
+		if s.cfg.DASHEnabled {
+			dashInputs = append(dashInputs, outputPath)
+		} else {
+			os.Remove(outputPath)
+		}
 
 		if err == nil {
-			qualityVersions[quality] = fmt.Sprintf("/videos/%s/%s", videoID, quality)
+			qualityVersions[r.Name] = asset.URL()
+			qualities = append(qualities, r.Name)
 		}
 	}
 
-	log.Printf("Video transcoding tugadi: %s", videoID)
-	return qualityVersions, nil
-}
+	// Master playlist
+	if len(packaged) > 0 {
+		if err := s.uploadMasterPlaylist(ctx, videoID, packaged); err != nil {
+			log.Printf("HLS master playlist xatosi: %v", err)
+		}
+	}
 
-// Thumbnail yaratish
-func (s *ProcessingService) GenerateThumbnail(ctx context.Context, videoID gocql.UUID, fileName string) (string, error) {
-	log.Printf("Thumbnail yaratish boshlandi: %s", videoID)
+	// DASH manifest (fMP4 segmentlar)
+	if len(dashInputs) > 0 {
+		s.publishProgress(ctx, Progress{VideoID: videoID, Stage: "dash", RenditionCount: len(renditions), OverallPercent: 100})
+		// Probe xato bergan bo'lsa audio borligi noma'lum - faqat video yo'laklari
+		hasAudio := info != nil && info.Audio != nil
+		if err := s.packageDASH(ctx, videoID, dashInputs, hasAudio, workDir); err != nil {
+			log.Printf("DASH xatosi: %v", err)
+		}
+	}
 
-	// MinIOdan raw videoni yuklab olish
-	objectName := fmt.Sprintf("raw/%s-%s", videoID.String(), fileName)
-	object, err := s.minio.GetObject(ctx, "videos-raw", objectName, minio.GetObjectOptions{})
-	if err != nil {
-		return "", fmt.Errorf("MinIOdan yuklash xatosi: %w", err)
+	if len(qualities) == 0 {
+		return nil, fmt.Errorf("hech bir sifat tayyorlanmadi")
 	}
-	defer object.Close()
 
-	// Vaqtinchalik faylga saqlash
-	tempDir := os.TempDir()
-	inputPath := filepath.Join(tempDir, fmt.Sprintf("%s-input.mp4", videoID))
-	outputPath := filepath.Join(tempDir, fmt.Sprintf("%s-thumb.jpg", videoID))
+	s.publishProgress(ctx, Progress{VideoID: videoID, Stage: "done", RenditionCount: len(renditions), Percent: 100, OverallPercent: 100})
+	log.Printf("Video transcoding tugadi: %s", videoID)
+	return &TranscodeResult{QualityVersions: qualityVersions, Qualities: qualities}, nil
+}
 
-	outputFile, err := os.Create(inputPath)
+// ProbeVideo raw faylni transcoder bilan tekshiradi (o'lcham, fps, codec, bitrate, davomiylik)
+func (s *ProcessingService) ProbeVideo(ctx context.Context, videoID gocql.UUID, fileName string) (*models.MediaInfo, error) {
+	ws, inputPath, err := s.openSource(ctx, videoID, fileName)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	io.Copy(outputFile, object)
-	outputFile.Close()
-	defer os.Remove(inputPath)
-
-	// FFmpeg bilan thumbnail yaratish (5-soniyada)
-	cmd := exec.Command("ffmpeg",
-		"-i", inputPath,
-		"-ss", "00:00:05",
-		"-vframes", "1",
-		"-vf", "scale=1280:720",
-		"-q:v", "2",
-		outputPath,
-	)
+	defer ws.Release()
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("thumbnail yaratish xatosi: %w", err)
-	}
-	defer os.Remove(outputPath)
+	return s.transcoder.Probe(ctx, inputPath)
+}
 
-	// Thumbnailni MinIOga yuklash
-	file, err := os.Open(outputPath)
+// uploadFile lokal faylni storagega yuklaydi
+func (s *ProcessingService) uploadFile(ctx context.Context, bucket, objectName, filePath, contentType string) error {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer file.Close()
 
-	fileInfo, _ := file.Stat()
-	minioPath := fmt.Sprintf("%s/thumbnail.jpg", videoID)
-
-	_, err = s.minio.PutObject(ctx, "thumbnails", minioPath, file, fileInfo.Size(), minio.PutObjectOptions{
-		ContentType: "image/jpeg",
-	})
+	fileInfo, err := file.Stat()
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	thumbnailURL := fmt.Sprintf("/thumbnails/%s/thumbnail.jpg", videoID)
-	log.Printf("Thumbnail yaratildi: %s", videoID)
-	return thumbnailURL, nil
+	return s.store.Put(ctx, bucket, objectName, file, fileInfo.Size(), contentType)
 }
 
-// Video davomiyligini olish
-func (s *ProcessingService) GetVideoDuration(ctx context.Context, videoID gocql.UUID, fileName string) (int, error) {
-	objectName := fmt.Sprintf("raw/%s-%s", videoID.String(), fileName)
-	object, err := s.minio.GetObject(ctx, "videos-raw", objectName, minio.GetObjectOptions{})
-	if err != nil {
-		return 0, err
-	}
-	defer object.Close()
-
-	tempDir := os.TempDir()
-	inputPath := filepath.Join(tempDir, fmt.Sprintf("%s-input.mp4", videoID))
+// uploadAsset lokal faylni asset kalitiga yuklaydi
+func (s *ProcessingService) uploadAsset(ctx context.Context, asset assets.Asset, filePath string) error {
+	return s.uploadFile(ctx, asset.Bucket(s.buckets), asset.Key(), filePath, asset.ContentType())
+}
 
-	outputFile, err := os.Create(inputPath)
-	if err != nil {
-		return 0, err
+// publishProgress progressni tarqatadi; xato transcodingni to'xtatmaydi
+func (s *ProcessingService) publishProgress(ctx context.Context, p Progress) {
+	if s.progress == nil {
+		return
 	}
-	io.Copy(outputFile, object)
-	outputFile.Close()
-	defer os.Remove(inputPath)
-
-	// FFprobe bilan davomiylikni olish
-	cmd := exec.Command("ffprobe",
-		"-v", "error",
-		"-show_entries", "format=duration",
-		"-of", "default=noprint_wrappers=1:nokey=1",
-		inputPath,
-	)
-
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, err
+	if err := s.progress.Publish(ctx, p); err != nil {
+		log.Printf("Progress yuborilmadi (%s): %v", p.VideoID, err)
 	}
-
-	var duration float64
-	fmt.Sscanf(string(output), "%f", &duration)
-	return int(duration), nil
 }
@@ -0,0 +1,108 @@
+// services/progress.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/transcoder"
+	"github.com/gocql/gocql"
+	"github.com/redis/go-redis/v9"
+)
+
+// Progress - transcoding jarayonining joriy holati
+type Progress struct {
+	VideoID        gocql.UUID `json:"video_id"`
+	Stage          string     `json:"stage"` // transcode, dash, done, failed
+	Rendition      string     `json:"rendition,omitempty"`
+	RenditionIndex int        `json:"rendition_index"` // 1 dan boshlanadi
+	RenditionCount int        `json:"rendition_count"`
+	Percent        float64    `json:"percent"`         // joriy rendition, 0-100
+	OverallPercent float64    `json:"overall_percent"` // barcha renditionlar, 0-100
+	ETASeconds     int        `json:"eta_seconds"`     // joriy rendition tugashigacha
+	Speed          float64    `json:"speed"`           // ffmpeg tezligi (1.0 = real vaqt)
+	Error          string     `json:"error,omitempty"` // failed bosqichida
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Done jarayon tugaganmi (muvaffaqiyatli yoki xato bilan). "failed" joriy
+// urinishga tegishli - job qayta urinilsa progress yana boshlanadi.
+func (p Progress) Done() bool {
+	return p.Stage == "done" || p.Stage == "failed"
+}
+
+// progressTTL - oxirgi holat Redisda qancha saqlanadi
+const progressTTL = time.Hour
+
+func progressKey(videoID gocql.UUID) string { return "progress:" + videoID.String() }
+
+// ProgressService transcoding progressini Redis pub/sub orqali tarqatadi.
+// Oxirgi holat alohida kalitda saqlanadi - keyin ulangan klient ham darhol
+// joriy holatni oladi.
+type ProgressService struct {
+	redis *redis.Client
+}
+
+func NewProgressService(redis *redis.Client) *ProgressService {
+	return &ProgressService{redis: redis}
+}
+
+func (s *ProgressService) Publish(ctx context.Context, p Progress) error {
+	p.UpdatedAt = time.Now()
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, progressKey(p.VideoID), data, progressTTL)
+	pipe.Publish(ctx, progressKey(p.VideoID), data)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Latest oxirgi saqlangan holat (bo'lmasa nil)
+func (s *ProgressService) Latest(ctx context.Context, videoID gocql.UUID) (*Progress, error) {
+	data, err := s.redis.Get(ctx, progressKey(videoID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var p Progress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Subscribe video progress kanaliga obuna bo'ladi. Chaqiruvchi Close qilishi kerak.
+func (s *ProgressService) Subscribe(ctx context.Context, videoID gocql.UUID) *redis.PubSub {
+	return s.redis.Subscribe(ctx, progressKey(videoID))
+}
+
+// renditionProgress transcoder holatidan foiz va ETA hisoblaydi
+func renditionProgress(p transcoder.Progress, duration time.Duration) (percent float64, eta time.Duration) {
+	if p.End {
+		return 100, 0
+	}
+	if duration <= 0 {
+		return 0, 0
+	}
+
+	percent = float64(p.OutTime) / float64(duration) * 100
+	if percent > 100 {
+		percent = 100
+	}
+
+	if p.Speed > 0 {
+		remaining := duration - p.OutTime
+		if remaining > 0 {
+			eta = time.Duration(float64(remaining) / p.Speed)
+		}
+	}
+	return percent, eta
+}
@@ -0,0 +1,175 @@
+// services/sprites.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/gocql/gocql"
+)
+
+// Sprite sheet o'lchamlari: har bir JPEG da spriteColumns x spriteRows kadr
+const (
+	spriteColumns   = 10
+	spriteRows      = 10
+	maxSpriteFrames = 600 // juda uzun videolarda interval kattalashtiriladi
+)
+
+// spriteInterval kadrlar orasidagi vaqt: sozlangan interval, lekin kadrlar
+// soni maxSpriteFrames dan oshmasligi kerak
+func spriteInterval(configured time.Duration, duration time.Duration) time.Duration {
+	if configured <= 0 {
+		configured = 10 * time.Second
+	}
+	if least := duration / maxSpriteFrames; least > configured {
+		return least.Round(time.Second)
+	}
+	return configured
+}
+
+// GenerateSprites videodan har intervalda kadr olib sprite sheetlarga joylaydi
+// va seek bar preview uchun WebVTT track (#xywh=) yozadi. Track URLini qaytaradi.
+func (s *ProcessingService) GenerateSprites(ctx context.Context, videoID gocql.UUID, fileName string) (string, error) {
+	log.Printf("Sprite yaratish boshlandi: %s", videoID)
+
+	ws, inputPath, err := s.openSource(ctx, videoID, fileName)
+	if err != nil {
+		return "", err
+	}
+	defer ws.Release()
+
+	info, err := s.transcoder.Probe(ctx, inputPath)
+	if err != nil {
+		return "", fmt.Errorf("probe xatosi: %w", err)
+	}
+	duration := time.Duration(info.Duration * float64(time.Second))
+	interval := spriteInterval(s.cfg.SpriteInterval, duration)
+
+	workDir, err := ws.TempDir("sprites-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	framesDir := filepath.Join(workDir, "frames")
+	if err := os.Mkdir(framesDir, 0o755); err != nil {
+		return "", err
+	}
+
+	frames, err := s.transcoder.ExtractFrames(ctx, inputPath, framesDir, interval, s.cfg.SpriteTileWidth)
+	if err != nil {
+		return "", fmt.Errorf("kadrlar ajratish xatosi: %w", err)
+	}
+	if len(frames) == 0 {
+		return "", fmt.Errorf("kadrlar ajratish xatosi: hech bir kadr olinmadi")
+	}
+
+	outDir := filepath.Join(workDir, "out")
+	if err := os.Mkdir(outDir, 0o755); err != nil {
+		return "", err
+	}
+
+	var vtt bytes.Buffer
+	vtt.WriteString("WEBVTT\n\n")
+
+	perSheet := spriteColumns * spriteRows
+	for sheet := 0; sheet*perSheet < len(frames); sheet++ {
+		batch := frames[sheet*perSheet : min((sheet+1)*perSheet, len(frames))]
+		sheetName := fmt.Sprintf("sprite-%03d.jpg", sheet)
+
+		tileWidth, tileHeight, err := writeSpriteSheet(filepath.Join(outDir, sheetName), batch)
+		if err != nil {
+			return "", fmt.Errorf("sprite sheet xatosi: %w", err)
+		}
+
+		for i := range batch {
+			index := sheet*perSheet + i
+			start := time.Duration(index) * interval
+			end := start + interval
+			if duration > 0 && end > duration {
+				end = duration
+			}
+			if end <= start {
+				end = start + time.Second
+			}
+
+			// Nisbiy URL - player .vtt manzilidan hisoblaydi
+			fmt.Fprintf(&vtt, "%s --> %s\n%s#xywh=%d,%d,%d,%d\n\n",
+				formatVTTTime(start), formatVTTTime(end), sheetName,
+				(i%spriteColumns)*tileWidth, (i/spriteColumns)*tileHeight, tileWidth, tileHeight)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(outDir, "thumbnails.vtt"), vtt.Bytes(), 0o644); err != nil {
+		return "", err
+	}
+
+	if err := s.uploadDir(ctx, s.buckets.Thumbnails, assets.Prefix(videoID, assets.Sprites), outDir); err != nil {
+		return "", err
+	}
+
+	log.Printf("Sprite yaratildi: %s (%d kadr, %s interval)", videoID, len(frames), interval)
+	return assets.Of(videoID, assets.Sprites, "thumbnails.vtt").URL(), nil
+}
+
+// writeSpriteSheet kadrlarni panjara ko'rinishida bitta JPEG ga joylaydi.
+// Tile o'lchami birinchi kadrdan olinadi.
+func writeSpriteSheet(output string, frames []string) (tileWidth, tileHeight int, err error) {
+	images := make([]image.Image, 0, len(frames))
+	for _, frame := range frames {
+		img, err := decodeJPEG(frame)
+		if err != nil {
+			return 0, 0, err
+		}
+		images = append(images, img)
+	}
+
+	bounds := images[0].Bounds()
+	tileWidth, tileHeight = bounds.Dx(), bounds.Dy()
+
+	columns := min(len(images), spriteColumns)
+	rows := (len(images) + spriteColumns - 1) / spriteColumns
+	sheet := image.NewRGBA(image.Rect(0, 0, columns*tileWidth, rows*tileHeight))
+
+	for i, img := range images {
+		x, y := (i%spriteColumns)*tileWidth, (i/spriteColumns)*tileHeight
+		rect := image.Rect(x, y, x+tileWidth, y+tileHeight)
+		draw.Draw(sheet, rect, img, img.Bounds().Min, draw.Src)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	if err := jpeg.Encode(file, sheet, &jpeg.Options{Quality: 75}); err != nil {
+		return 0, 0, err
+	}
+	return tileWidth, tileHeight, nil
+}
+
+func decodeJPEG(filePath string) (image.Image, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return jpeg.Decode(file)
+}
+
+// formatVTTTime WebVTT vaqt formati: HH:MM:SS.mmm
+func formatVTTTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,299 @@
+// services/thumbnails.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // custom thumbnail PNG bo'lishi mumkin
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/gocql/gocql"
+)
+
+var (
+	ErrOwnerMismatch     = errors.New("user_id video egasiga mos kelmaydi")
+	ErrCandidateNotFound = errors.New("thumbnail varianti topilmadi")
+	ErrInvalidThumbnail  = errors.New("thumbnail rasmi noto'g'ri")
+	ErrThumbnailTooLarge = errors.New("thumbnail rasmi juda katta")
+)
+
+// Thumbnail o'lchamlari (aspect ratio saqlanadi)
+const (
+	thumbnailCandidates = 5
+	thumbnailWidth      = 1280
+	thumbnailHeight     = 720
+	minThumbnailWidth   = 320
+	minThumbnailHeight  = 180
+	maxThumbnailPixels  = 40_000_000 // decompression bomb'dan himoya
+	maxThumbnailUpload  = 10 << 20
+)
+
+// candidateTimes videoning teng bo'laklaridagi vaqtlar (boshi va oxiri
+// kiritilmaydi - ular ko'pincha qora ekran yoki titrlar)
+func candidateTimes(duration time.Duration, count int) []time.Duration {
+	if duration <= 0 {
+		return []time.Duration{0}
+	}
+
+	times := make([]time.Duration, count)
+	for i := range times {
+		times[i] = duration * time.Duration(i+1) / time.Duration(count+1)
+	}
+	return times
+}
+
+// GenerateThumbnails videoning bir necha nuqtasidan thumbnail variantlarini
+// oladi va baholaydi. Eng yuqori ballisi Selected deb belgilanadi.
+func (s *ProcessingService) GenerateThumbnails(ctx context.Context, videoID gocql.UUID, fileName string) ([]models.ThumbnailCandidate, error) {
+	log.Printf("Thumbnail yaratish boshlandi: %s", videoID)
+
+	// Raw videoni workspace'dan olish (transcode bilan umumiy)
+	ws, inputPath, err := s.openSource(ctx, videoID, fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Release()
+
+	workDir, err := ws.TempDir("thumbnail-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	var duration time.Duration // noma'lum bo'lsa faqat birinchi kadr
+	if info, err := s.transcoder.Probe(ctx, inputPath); err != nil {
+		log.Printf("ffprobe xatosi (%s): %v", videoID, err)
+	} else {
+		duration = time.Duration(info.Duration * float64(time.Second))
+	}
+
+	var candidates []models.ThumbnailCandidate
+	best := -1
+	for i, at := range candidateTimes(duration, thumbnailCandidates) {
+		file := fmt.Sprintf("candidate-%d.jpg", i)
+		outputPath := filepath.Join(workDir, file)
+
+		if err := s.transcoder.ExtractFrame(ctx, inputPath, outputPath, at, thumbnailWidth, 0); err != nil {
+			log.Printf("Thumbnail xatosi (%s, %s): %v", videoID, at, err)
+			continue
+		}
+
+		img, err := decodeJPEG(outputPath)
+		if err != nil {
+			log.Printf("Thumbnail o'qilmadi (%s, %s): %v", videoID, at, err)
+			continue
+		}
+
+		asset := assets.Of(videoID, assets.Thumbnails, file)
+		if err := s.uploadAsset(ctx, asset, outputPath); err != nil {
+			return nil, err
+		}
+
+		candidates = append(candidates, models.ThumbnailCandidate{
+			URL:   asset.URL(),
+			At:    at.Seconds(),
+			Score: math.Round(frameScore(img)*100) / 100,
+		})
+		if best < 0 || candidates[len(candidates)-1].Score > candidates[best].Score {
+			best = len(candidates) - 1
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("thumbnail yaratish xatosi: hech bir kadr olinmadi")
+	}
+	candidates[best].Selected = true
+
+	log.Printf("Thumbnail yaratildi: %s (%d variant)", videoID, len(candidates))
+	return candidates, nil
+}
+
+// frameScore kadrning "ma'noliligi": yorqinlikning standart og'ishi (kontrast).
+// Deyarli butunlay qora yoki oq kadrlar (fade, titr foni) jarimalanadi.
+func frameScore(img image.Image) float64 {
+	bounds := img.Bounds()
+	// Har bir pikselni emas, ~100x100 panjarani tekshirish yetarli
+	step := max(1, max(bounds.Dx(), bounds.Dy())/100)
+
+	var sum, sumSq, n float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			luma := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+			sum += luma
+			sumSq += luma * luma
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+
+	mean := sum / n
+	stddev := math.Sqrt(math.Max(0, sumSq/n-mean*mean))
+	if mean < 20 || mean > 235 {
+		return stddev * 0.1
+	}
+	return stddev
+}
+
+// ownedVideo videoni oladi va so'rovdagi userID uning egasiga mosligini tekshiradi.
+// Bu access control EMAS: autentifikatsiya hali yo'q va user_id ni mijoz o'zi
+// yuboradi (videoning user_id si javoblarda ochiq). Tekshiruv faqat xato
+// videoni o'zgartirib yuborishdan saqlaydi; haqiqiy ega tekshiruvi
+// autentifikatsiya qo'shilganda token'dagi foydalanuvchi bo'yicha bo'ladi.
+func (s *VideoService) ownedVideo(ctx context.Context, videoID, userID string) (*models.Video, error) {
+	video, err := s.GetVideo(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := gocql.ParseUUID(userID)
+	if err != nil || owner != video.UserID {
+		return nil, ErrOwnerMismatch
+	}
+	return video, nil
+}
+
+// SelectThumbnail generatsiya qilingan variantlardan birini asosiy thumbnail qiladi
+func (s *VideoService) SelectThumbnail(ctx context.Context, videoID, userID string, index int) (*models.Video, error) {
+	video, err := s.ownedVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(video.ThumbnailCandidates) {
+		return nil, ErrCandidateNotFound
+	}
+
+	for i := range video.ThumbnailCandidates {
+		video.ThumbnailCandidates[i].Selected = i == index
+	}
+	video.ThumbnailURL = video.ThumbnailCandidates[index].URL
+
+	if err := s.repos.Videos.SetThumbnail(ctx, video.ID, video.ThumbnailURL, video.ThumbnailCandidates); err != nil {
+		return nil, err
+	}
+	s.syncChannelEntry(ctx, video)
+	return video, nil
+}
+
+// UploadThumbnail foydalanuvchi rasmini tekshiradi, kichraytiradi va JPEG
+// ko'rinishida asosiy thumbnail sifatida saqlaydi
+func (s *VideoService) UploadThumbnail(ctx context.Context, videoID, userID string, r io.Reader) (*models.Video, error) {
+	video, err := s.ownedVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := prepareCustomThumbnail(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Har safar yangi nom - eski rasm CDN/brauzer keshida qolib ketmasligi uchun
+	asset := assets.Of(video.ID, assets.Thumbnails, fmt.Sprintf("custom-%d.jpg", time.Now().Unix()))
+	if err := s.store.Put(ctx, asset.Bucket(s.buckets), asset.Key(), bytes.NewReader(data), int64(len(data)), asset.ContentType()); err != nil {
+		return nil, fmt.Errorf("storagega yuklash xatosi: %w", err)
+	}
+
+	for i := range video.ThumbnailCandidates {
+		video.ThumbnailCandidates[i].Selected = false
+	}
+	video.ThumbnailURL = asset.URL()
+
+	if err := s.repos.Videos.SetThumbnail(ctx, video.ID, video.ThumbnailURL, video.ThumbnailCandidates); err != nil {
+		return nil, err
+	}
+	s.syncChannelEntry(ctx, video)
+	return video, nil
+}
+
+// prepareCustomThumbnail JPEG/PNG rasmni tekshiradi va thumbnail o'lchamiga keltiradi
+func prepareCustomThumbnail(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxThumbnailUpload+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxThumbnailUpload {
+		return nil, ErrThumbnailTooLarge
+	}
+
+	// Avval faqat sarlavhani o'qib o'lchamni tekshirish (butun rasmni ochmasdan)
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, fmt.Errorf("%w: faqat JPEG yoki PNG", ErrInvalidThumbnail)
+	}
+	if config.Width < minThumbnailWidth || config.Height < minThumbnailHeight {
+		return nil, fmt.Errorf("%w: kamida %dx%d bo'lishi kerak", ErrInvalidThumbnail, minThumbnailWidth, minThumbnailHeight)
+	}
+	if config.Width*config.Height > maxThumbnailPixels {
+		return nil, ErrThumbnailTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidThumbnail, err)
+	}
+
+	img = resizeToFit(img, thumbnailWidth, thumbnailHeight)
+
+	// JPEG da shaffoflik yo'q - oq fonga joylash
+	bounds := img.Bounds()
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(canvas, canvas.Bounds(), img, bounds.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, canvas, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resizeToFit rasmni maxWidth x maxHeight ichiga sig'adigan qilib kichraytiradi
+// (kattalashtirmaydi). Har bir yangi piksel manbadagi mos blokning o'rtachasi.
+func resizeToFit(src image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxWidth && height <= maxHeight {
+		return src
+	}
+
+	scale := math.Min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	dstWidth := max(1, int(float64(width)*scale))
+	dstHeight := max(1, int(float64(height)*scale))
+	dst := image.NewRGBA64(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := bounds.Min.Y + (y+1)*height/dstHeight
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := bounds.Min.X + (x+1)*width/dstWidth
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
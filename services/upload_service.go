@@ -0,0 +1,374 @@
+// services/upload_service.go
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+
+	"github.com/gocql/gocql"
+	"github.com/redis/go-redis/v9"
+)
+
+// S3/MinIO multipart uchun eng kichik part hajmi (oxirgi partdan tashqari)
+const minPartSize = 5 * 1024 * 1024
+
+const tusExpiryKey = "tus:expiry"
+
+var (
+	ErrUploadNotFound = errors.New("upload topilmadi")
+	ErrOffsetMismatch = errors.New("Upload-Offset mos kelmadi")
+	ErrUploadLocked   = errors.New("upload boshqa so'rov tomonidan yozilmoqda")
+	ErrUploadTooLarge = errors.New("upload hajmi juda katta")
+)
+
+// TusUpload - tugallanmagan resumable upload holati (Redisda saqlanadi)
+type TusUpload struct {
+	ID         gocql.UUID            `json:"id"`
+	Length     int64                 `json:"length"`
+	Offset     int64                 `json:"offset"`
+	Metadata   models.UploadMetadata `json:"metadata"`
+	ExpiresAt  time.Time             `json:"expires_at"`
+	UploadID   string                `json:"upload_id"` // storage multipart upload ID
+	ObjectName string                `json:"object_name"`
+	Parts      []storage.Part        `json:"parts"`
+	Completed  bool                  `json:"completed"` // multipart yig'ilgan, video hali yaratilmagan
+}
+
+// UploadService tus 1.0 resumable va presigned uploadlarni storage multipart upload orqali boshqaradi
+type UploadService struct {
+	store        storage.ObjectStore
+	buckets      config.Buckets
+	redis        *redis.Client
+	videoService *VideoService
+	cfg          config.UploadConfig
+}
+
+func NewUploadService(store storage.ObjectStore, buckets config.Buckets, redis *redis.Client, videoService *VideoService, cfg config.UploadConfig) *UploadService {
+	return &UploadService{
+		store:        store,
+		buckets:      buckets,
+		redis:        redis,
+		videoService: videoService,
+		cfg:          cfg,
+	}
+}
+
+func (s *UploadService) MaxSize() int64 {
+	return s.cfg.MaxSize
+}
+
+func tusKey(id gocql.UUID) string        { return "tus:" + id.String() }
+func tusPendingKey(id gocql.UUID) string { return "tus:" + id.String() + ":pending" }
+func tusLockKey(id gocql.UUID) string    { return "tus:" + id.String() + ":lock" }
+
+// CreateUpload yangi upload yaratadi (tus "creation" extension)
+func (s *UploadService) CreateUpload(ctx context.Context, length int64, meta models.UploadMetadata) (*TusUpload, error) {
+	if length > s.cfg.MaxSize {
+		return nil, ErrUploadTooLarge
+	}
+	if _, err := parseUserID(meta.UserID); err != nil {
+		return nil, err
+	}
+
+	upload := &TusUpload{
+		ID:        gocql.TimeUUID(),
+		Length:    length,
+		Metadata:  meta,
+		ExpiresAt: time.Now().Add(s.cfg.Expiration),
+	}
+	upload.ObjectName = RawObjectName(upload.ID, meta.FileName)
+
+	uploadID, err := s.store.NewMultipartUpload(ctx, s.buckets.Raw, upload.ObjectName, meta.FileType)
+	if err != nil {
+		return nil, fmt.Errorf("multipart upload xatosi: %w", err)
+	}
+	upload.UploadID = uploadID
+
+	if err := s.save(ctx, upload, nil); err != nil {
+		s.store.AbortMultipartUpload(ctx, s.buckets.Raw, upload.ObjectName, uploadID)
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+// GetUpload upload holatini qaytaradi (HEAD so'rovi uchun)
+func (s *UploadService) GetUpload(ctx context.Context, uploadID string) (*TusUpload, error) {
+	id, err := gocql.ParseUUID(uploadID)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+
+	data, err := s.redis.Get(ctx, tusKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var upload TusUpload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, err
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadNotFound
+	}
+
+	return &upload, nil
+}
+
+// WriteChunk PATCH so'rovi tanasini (length bayt) oqim sifatida yozadi: tana
+// xotiraga to'liq o'qilmaydi, har 5MB to'lganda storagega part sifatida
+// yuklanadi. Ulanish uzilsa qabul qilingan qism saqlanadi (klient HEAD bilan
+// davom etadi). Upload tugasa video yaratiladi va processing joblari navbatga qo'shiladi.
+func (s *UploadService) WriteChunk(ctx context.Context, uploadID string, offset int64, body io.Reader, length int64) (*TusUpload, *models.Video, error) {
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Bir vaqtda faqat bitta PATCH
+	locked, err := s.redis.SetNX(ctx, tusLockKey(upload.ID), 1, 5*time.Minute).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !locked {
+		return nil, nil, ErrUploadLocked
+	}
+	defer s.redis.Del(ctx, tusLockKey(upload.ID))
+
+	// Lock olingandan keyin holatni qayta o'qish
+	upload, err = s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset != upload.Offset {
+		return upload, nil, ErrOffsetMismatch
+	}
+
+	// Obyekt yig'ilgan, lekin video yaratilmagan (oldingi urinishda xato) -
+	// klient oxirgi PATCHni qaytaradi, tana endi kerak emas
+	if upload.Completed {
+		return s.finishUpload(ctx, upload)
+	}
+	if upload.Offset+length > upload.Length {
+		return upload, nil, ErrUploadTooLarge
+	}
+
+	// Oldingi PATCHdan qolgan (5MB dan kichik) baytlar
+	pending, err := s.redis.Get(ctx, tusPendingKey(upload.ID)).Bytes()
+	if err != nil && err != redis.Nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, minPartSize)
+	filled := copy(buf, pending)
+	r := io.LimitReader(body, length)
+
+	var readErr error
+	for received := int64(0); received < length; {
+		n, err := io.ReadFull(r, buf[filled:])
+		filled += n
+		received += int64(n)
+		upload.Offset += int64(n)
+
+		if filled == len(buf) {
+			if err := s.uploadPart(ctx, upload, buf); err != nil {
+				return nil, nil, err
+			}
+			filled = 0
+			// Part va yangi offset birga saqlanadi - ulanish keyin uzilsa ham yo'qolmaydi
+			if err := s.save(ctx, upload, nil); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			if received < length {
+				readErr = io.ErrUnexpectedEOF
+			}
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+	}
+
+	final := upload.Offset == upload.Length
+	if final && filled > 0 {
+		if err := s.uploadPart(ctx, upload, buf[:filled]); err != nil {
+			return nil, nil, err
+		}
+		filled = 0
+	}
+
+	if !final {
+		upload.ExpiresAt = time.Now().Add(s.cfg.Expiration)
+		if err := s.save(ctx, upload, buf[:filled]); err != nil {
+			return nil, nil, err
+		}
+		return upload, nil, readErr
+	}
+
+	// Upload tugadi - obyektni yig'ish va video yaratish
+	err = s.store.CompleteMultipartUpload(ctx, s.buckets.Raw, upload.ObjectName, upload.UploadID, upload.Parts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("multipart yakunlash xatosi: %w", err)
+	}
+
+	// Offset oxirgi PATCH boshiga qaytariladi: video yaratilmasa klient shu
+	// PATCHni qayta yuboradi va finishUpload qayta uriniladi
+	upload.Completed = true
+	upload.Offset = offset
+	if err := s.save(ctx, upload, nil); err != nil {
+		return nil, nil, err
+	}
+	return s.finishUpload(ctx, upload)
+}
+
+// finishUpload yig'ilgan obyekt uchun video yaratadi. Tus holati faqat video
+// yozuvi saqlangandan keyin o'chiriladi, aks holda klient qayta urinib ko'ra oladi.
+func (s *UploadService) finishUpload(ctx context.Context, upload *TusUpload) (*TusUpload, *models.Video, error) {
+	userID, err := parseUserID(upload.Metadata.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	video, err := s.videoService.CreateVideo(ctx, upload.ID, upload.Metadata.Title, upload.Metadata.Description,
+		userID, upload.Metadata.Username, upload.Length, upload.Metadata.FileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.delete(ctx, upload.ID)
+
+	upload.Offset = upload.Length
+	return upload, video, nil
+}
+
+// uploadPart navbatdagi partni yuklaydi va uploadga qo'shadi
+func (s *UploadService) uploadPart(ctx context.Context, upload *TusUpload, data []byte) error {
+	partNumber := len(upload.Parts) + 1
+	part, err := s.store.UploadPart(ctx, s.buckets.Raw, upload.ObjectName, upload.UploadID,
+		partNumber, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("part yuklash xatosi: %w", err)
+	}
+	upload.Parts = append(upload.Parts, part)
+	return nil
+}
+
+// TerminateUpload uploadni bekor qiladi (tus "termination" extension)
+func (s *UploadService) TerminateUpload(ctx context.Context, uploadID string) error {
+	upload, err := s.GetUpload(ctx, uploadID)
+	if err != nil {
+		return err
+	}
+	return s.abort(ctx, upload)
+}
+
+// ExpireUploads muddati o'tgan uploadlarni tozalaydi (tus "expiration" extension)
+func (s *UploadService) ExpireUploads(ctx context.Context) (int, error) {
+	ids, err := s.redis.ZRangeByScore(ctx, tusExpiryKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprint(time.Now().Unix()),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, rawID := range ids {
+		id, err := gocql.ParseUUID(rawID)
+		if err != nil {
+			s.redis.ZRem(ctx, tusExpiryKey, rawID)
+			continue
+		}
+
+		data, err := s.redis.Get(ctx, tusKey(id)).Bytes()
+		if err != nil {
+			s.redis.ZRem(ctx, tusExpiryKey, rawID)
+			continue
+		}
+
+		var upload TusUpload
+		if err := json.Unmarshal(data, &upload); err != nil {
+			s.delete(ctx, id)
+			continue
+		}
+		if time.Now().Before(upload.ExpiresAt) {
+			// PATCH muddatni uzaytirgan
+			continue
+		}
+
+		if err := s.abort(ctx, &upload); err != nil {
+			log.Printf("Upload tozalash xatosi (%s): %v", upload.ID, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+func (s *UploadService) abort(ctx context.Context, upload *TusUpload) error {
+	if upload.Completed {
+		// Multipart allaqachon yig'ilgan - video yaratilmagan obyektni o'chirish
+		err := s.store.Delete(ctx, s.buckets.Raw, upload.ObjectName)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("obyektni o'chirish xatosi: %w", err)
+		}
+		s.delete(ctx, upload.ID)
+		return nil
+	}
+
+	err := s.store.AbortMultipartUpload(ctx, s.buckets.Raw, upload.ObjectName, upload.UploadID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("multipart bekor qilish xatosi: %w", err)
+	}
+	s.delete(ctx, upload.ID)
+	return nil
+}
+
+// save upload holatini va partga yig'ilmagan baytlarni (pending) bitta
+// tranzaksiyada yozadi: offset va pending har doim bir-biriga mos bo'ladi
+func (s *UploadService) save(ctx context.Context, upload *TusUpload, pending []byte) error {
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+
+	// Expiration worker multipart uploadni bekor qilishi uchun holat biroz uzoqroq saqlanadi
+	ttl := time.Until(upload.ExpiresAt) + time.Hour
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, tusKey(upload.ID), data, ttl)
+	if len(pending) > 0 {
+		pipe.Set(ctx, tusPendingKey(upload.ID), pending, ttl)
+	} else {
+		pipe.Del(ctx, tusPendingKey(upload.ID))
+	}
+	pipe.ZAdd(ctx, tusExpiryKey, redis.Z{
+		Score:  float64(upload.ExpiresAt.Unix()),
+		Member: upload.ID.String(),
+	})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *UploadService) delete(ctx context.Context, id gocql.UUID) {
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, tusKey(id), tusPendingKey(id))
+	pipe.ZRem(ctx, tusExpiryKey, id.String())
+	pipe.Exec(ctx)
+}
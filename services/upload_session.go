@@ -0,0 +1,257 @@
+// services/upload_session.go
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+
+	"github.com/gocql/gocql"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sessionPartSize = 64 * 1024 * 1024 // presigned upload part hajmi
+	maxUploadParts  = 10000            // S3/MinIO limiti
+	sessionTTL      = 12 * time.Hour   // presigned URL va sessiya muddati
+)
+
+var (
+	ErrSessionNotFound  = errors.New("upload sessiyasi topilmadi")
+	ErrUploadIncomplete = errors.New("fayl to'liq yuklanmagan")
+)
+
+// UploadSession - presigned (direct-to-MinIO) upload sessiyasi
+type UploadSession struct {
+	VideoID    gocql.UUID    `json:"video_id"`
+	UploadID   string        `json:"upload_id"`
+	ObjectName string        `json:"object_name"`
+	FileType   string        `json:"file_type"`
+	FileSize   int64         `json:"file_size"`
+	PartSize   int64         `json:"part_size"`
+	Parts      []PresignPart `json:"parts"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+}
+
+// PresignPart bitta part uchun PUT URL
+type PresignPart struct {
+	PartNumber int    `json:"part_number"`
+	URL        string `json:"url"`
+}
+
+// sessionExpiryKey muddati bo'yicha saralangan sessiyalar (ExpireUploadSessions uchun)
+const sessionExpiryKey = "upload_session:expiry"
+
+func sessionKey(videoID gocql.UUID) string { return "upload_session:" + videoID.String() }
+
+// CreateUploadSession "uploading" holatida video yaratadi va har bir part uchun
+// presigned PUT URL qaytaradi. Klient faylni to'g'ridan-to'g'ri MinIOga yuklaydi.
+func (s *UploadService) CreateUploadSession(ctx context.Context, req models.UploadSessionRequest) (*models.Video, *UploadSession, error) {
+	if req.FileSize > s.cfg.MaxSize {
+		return nil, nil, ErrUploadTooLarge
+	}
+	userID, err := parseUserID(req.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	videoID := gocql.TimeUUID()
+	session := &UploadSession{
+		VideoID:    videoID,
+		ObjectName: RawObjectName(videoID, req.FileName),
+		FileType:   req.FileType,
+		FileSize:   req.FileSize,
+		PartSize:   sessionPartSize,
+		ExpiresAt:  time.Now().Add(sessionTTL),
+	}
+	if session.FileSize > session.PartSize*maxUploadParts {
+		session.PartSize = (session.FileSize + maxUploadParts - 1) / maxUploadParts
+	}
+
+	uploadID, err := s.store.NewMultipartUpload(ctx, s.buckets.Raw, session.ObjectName, req.FileType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("multipart upload xatosi: %w", err)
+	}
+	session.UploadID = uploadID
+
+	partCount := int((session.FileSize + session.PartSize - 1) / session.PartSize)
+	for n := 1; n <= partCount; n++ {
+		u, err := s.store.PresignUploadPart(ctx, s.buckets.Raw, session.ObjectName, uploadID, n, sessionTTL)
+		if err != nil {
+			s.store.AbortMultipartUpload(ctx, s.buckets.Raw, session.ObjectName, uploadID)
+			return nil, nil, fmt.Errorf("presign xatosi: %w", err)
+		}
+		session.Parts = append(session.Parts, PresignPart{PartNumber: n, URL: u})
+	}
+
+	video, err := s.videoService.CreatePendingVideo(ctx, videoID, req.Title, req.Description,
+		userID, req.Username, req.FileSize, req.FileName)
+	if err != nil {
+		s.store.AbortMultipartUpload(ctx, s.buckets.Raw, session.ObjectName, uploadID)
+		return nil, nil, err
+	}
+
+	// Sessiya muddatidan keyin ham biroz saqlanadi - tozalovchi multipart
+	// uploadni bekor qilishi va videoni failed qilishi uchun
+	data, _ := json.Marshal(session)
+	pipe := s.redis.TxPipeline()
+	pipe.Set(ctx, sessionKey(videoID), data, sessionTTL+24*time.Hour)
+	pipe.ZAdd(ctx, sessionExpiryKey, redis.Z{
+		Score:  float64(session.ExpiresAt.Unix()),
+		Member: videoID.String(),
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	return video, session, nil
+}
+
+// CompleteUploadSession multipart uploadni yakunlaydi, obyektni StatObject bilan
+// tekshiradi va processing joblarini navbatga qo'shadi. parts bo'sh bo'lsa,
+// ular storagedan o'qiladi.
+func (s *UploadService) CompleteUploadSession(ctx context.Context, videoID string, parts []models.UploadedPart) (*models.Video, error) {
+	id, err := gocql.ParseUUID(videoID)
+	if err != nil {
+		return nil, ErrSessionNotFound
+	}
+
+	session, err := s.getSession(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+
+	// Oldingi urinishda multipart yakunlangan, lekin MarkUploaded xato bergan
+	// bo'lishi mumkin - bu holda upload ID allaqachon yopilgan, qayta yakunlanmaydi
+	info, err := s.store.Stat(ctx, s.buckets.Raw, session.ObjectName)
+	if errors.Is(err, storage.ErrNotFound) {
+		completeParts, err := s.sessionParts(ctx, session, parts)
+		if err != nil {
+			return nil, err
+		}
+		if len(completeParts) == 0 {
+			return nil, ErrUploadIncomplete
+		}
+
+		err = s.store.CompleteMultipartUpload(ctx, s.buckets.Raw, session.ObjectName, session.UploadID, completeParts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUploadIncomplete, err)
+		}
+
+		info, err = s.store.Stat(ctx, s.buckets.Raw, session.ObjectName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUploadIncomplete, err)
+	}
+
+	// Yig'ilgan obyekt kutilgan hajmda ekanini tekshirish
+	if info.Size != session.FileSize {
+		s.store.Delete(ctx, s.buckets.Raw, session.ObjectName)
+		s.deleteSession(ctx, id)
+		err := fmt.Errorf("%w: %d/%d bayt", ErrUploadIncomplete, info.Size, session.FileSize)
+		s.videoService.MarkFailed(ctx, id, err.Error())
+		return nil, err
+	}
+
+	if err := s.videoService.MarkUploaded(ctx, id); err != nil {
+		return nil, err
+	}
+	s.deleteSession(ctx, id)
+
+	return s.videoService.GetVideo(ctx, videoID)
+}
+
+// ExpireUploadSessions muddati o'tgan presigned sessiyalarni tozalaydi:
+// multipart upload bekor qilinadi, hali "uploading" holatidagi video failed qilinadi
+func (s *UploadService) ExpireUploadSessions(ctx context.Context) (int, error) {
+	ids, err := s.redis.ZRangeByScore(ctx, sessionExpiryKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprint(time.Now().Unix()),
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, rawID := range ids {
+		id, err := gocql.ParseUUID(rawID)
+		if err != nil {
+			s.redis.ZRem(ctx, sessionExpiryKey, rawID)
+			continue
+		}
+
+		session, err := s.getSession(ctx, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			s.redis.ZRem(ctx, sessionExpiryKey, rawID)
+			continue
+		}
+		if err != nil {
+			log.Printf("Upload sessiyasi o'qilmadi (%s): %v", id, err)
+			continue
+		}
+
+		err = s.store.AbortMultipartUpload(ctx, s.buckets.Raw, session.ObjectName, session.UploadID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Upload sessiyasi tozalash xatosi (%s): %v", id, err)
+			continue
+		}
+
+		video, err := s.videoService.GetVideo(ctx, rawID)
+		if err == nil && video.Status == "uploading" {
+			s.videoService.MarkFailed(ctx, id, "upload sessiyasi muddati tugadi")
+		}
+
+		s.deleteSession(ctx, id)
+		expired++
+	}
+
+	return expired, nil
+}
+
+func (s *UploadService) getSession(ctx context.Context, id gocql.UUID) (*UploadSession, error) {
+	data, err := s.redis.Get(ctx, sessionKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session UploadSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *UploadService) deleteSession(ctx context.Context, id gocql.UUID) {
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, sessionKey(id))
+	pipe.ZRem(ctx, sessionExpiryKey, id.String())
+	pipe.Exec(ctx)
+}
+
+func (s *UploadService) sessionParts(ctx context.Context, session *UploadSession, parts []models.UploadedPart) ([]storage.Part, error) {
+	if len(parts) == 0 {
+		listed, err := s.store.ListParts(ctx, s.buckets.Raw, session.ObjectName, session.UploadID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUploadIncomplete, err)
+		}
+		return listed, nil
+	}
+
+	completeParts := make([]storage.Part, len(parts))
+	for i, p := range parts {
+		completeParts[i] = storage.Part{PartNumber: p.PartNumber, ETag: p.ETag}
+	}
+	return completeParts, nil
+}
@@ -6,42 +6,109 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/Coding-for-Machine/Videos-Service/config"
 	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/queue"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
 
 	"github.com/gocql/gocql"
-	"github.com/minio/minio-go/v7"
 	"github.com/redis/go-redis/v9"
 )
 
 type VideoService struct {
-	cassandra *gocql.Session
-	minio     *minio.Client
-	redis     *redis.Client
+	repos   repository.Repositories
+	store   storage.ObjectStore
+	buckets config.Buckets
+	redis   *redis.Client
+	jobs    queue.Queues
 }
 
-func NewVideoService(cassandra *gocql.Session, minio *minio.Client, redis *redis.Client) *VideoService {
+func NewVideoService(repos repository.Repositories, store storage.ObjectStore, buckets config.Buckets, redis *redis.Client, jobs queue.Queues) *VideoService {
 	return &VideoService{
-		cassandra: cassandra,
-		minio:     minio,
-		redis:     redis,
+		repos:   repos,
+		store:   store,
+		buckets: buckets,
+		redis:   redis,
+		jobs:    jobs,
 	}
 }
 
-func (s *VideoService) UploadVideo(ctx context.Context, title, description, username string, file io.Reader, fileSize int64, fileName string) (*models.Video, error) {
+// RawObjectName raw bucketdagi video obyekt nomi
+func RawObjectName(videoID gocql.UUID, fileName string) string {
+	return fmt.Sprintf("raw/%s-%s", videoID.String(), fileName)
+}
+
+// parseUserID so'rovdagi user_id (video egasi, kanal partition kaliti)
+func parseUserID(userID string) (gocql.UUID, error) {
+	id, err := gocql.ParseUUID(userID)
+	if err != nil || id == (gocql.UUID{}) {
+		return gocql.UUID{}, ErrInvalidUserID
+	}
+	return id, nil
+}
+
+func (s *VideoService) UploadVideo(ctx context.Context, title, description, userID, username string, file io.Reader, fileSize int64, fileName string) (*models.Video, error) {
+	owner, err := parseUserID(userID)
+	if err != nil {
+		return nil, err
+	}
 	videoID := gocql.TimeUUID()
-	userID := gocql.TimeUUID() // Haqiqiy user authentication kerak
 
-	// MinIOga yuklash (raw bucket)
-	objectName := fmt.Sprintf("raw/%s-%s", videoID.String(), fileName)
-	_, err := s.minio.PutObject(ctx, "videos-raw", objectName, file, fileSize, minio.PutObjectOptions{
-		ContentType: "video/mp4",
-	})
+	// Storagega yuklash (raw bucket)
+	objectName := RawObjectName(videoID, fileName)
+	err = s.store.Put(ctx, s.buckets.Raw, objectName, file, fileSize, "video/mp4")
+	if err != nil {
+		return nil, fmt.Errorf("storagega yuklash xatosi: %w", err)
+	}
+
+	return s.CreateVideo(ctx, videoID, title, description, owner, username, fileSize, fileName)
+}
+
+// CreateVideo raw fayl storagega yuklangandan keyin video yozuvini yaratadi
+// va processing joblarini navbatga qo'shadi
+func (s *VideoService) CreateVideo(ctx context.Context, videoID gocql.UUID, title, description string, userID gocql.UUID, username string, fileSize int64, fileName string) (*models.Video, error) {
+	video, err := s.insertVideo(ctx, videoID, title, description, userID, username, fileSize, fileName, "processing")
+	if err != nil {
+		return nil, err
+	}
+
+	s.enqueueProcessingJobs(ctx, videoID)
+
+	return video, nil
+}
+
+// CreatePendingVideo fayl hali yuklanmagan (presigned upload) video yozuvini yaratadi
+func (s *VideoService) CreatePendingVideo(ctx context.Context, videoID gocql.UUID, title, description string, userID gocql.UUID, username string, fileSize int64, fileName string) (*models.Video, error) {
+	return s.insertVideo(ctx, videoID, title, description, userID, username, fileSize, fileName, "uploading")
+}
+
+// MarkUploaded "uploading" holatidagi videoni processingga o'tkazadi va
+// endi ko'rinadigan videoni kanal ro'yxati hamda qidiruvga qo'shadi
+func (s *VideoService) MarkUploaded(ctx context.Context, videoID gocql.UUID) error {
+	if err := s.repos.Videos.SetStatus(ctx, videoID, "processing"); err != nil {
+		return err
+	}
+
+	video, err := s.repos.Videos.Get(ctx, videoID)
 	if err != nil {
-		return nil, fmt.Errorf("MinIOga yuklash xatosi: %w", err)
+		return err
 	}
+	s.indexVideo(ctx, video)
 
+	s.enqueueProcessingJobs(ctx, videoID)
+	return nil
+}
+
+// insertVideo video yozuvini saqlaydi. userID so'rovdan keladi (autentifikatsiya
+// hali yo'q, qarang ownedVideo) va kanal ro'yxati shu bo'yicha yig'iladi.
+func (s *VideoService) insertVideo(ctx context.Context, videoID gocql.UUID, title, description string, userID gocql.UUID, username string, fileSize int64, fileName, status string) (*models.Video, error) {
 	// Video ma'lumotlarini Cassandraga saqlash
 	video := &models.Video{
 		ID:              videoID,
@@ -51,24 +118,67 @@ func (s *VideoService) UploadVideo(ctx context.Context, title, description, user
 		Username:        username,
 		FileName:        fileName,
 		FileSize:        fileSize,
-		Status:          "processing",
+		Status:          status,
 		QualityVersions: make(map[string]string),
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 	}
 
-	query := `INSERT INTO videos (id, title, description, user_id, username, file_name, 
-		file_size, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	err = s.cassandra.Query(query, video.ID, video.Title, video.Description,
-		video.UserID, video.Username, video.FileName, video.FileSize,
-		video.Status, video.CreatedAt, video.UpdatedAt).Exec()
-	if err != nil {
+	if err := s.repos.Videos.Create(ctx, video); err != nil {
 		return nil, fmt.Errorf("Cassandraga saqlash xatosi: %w", err)
 	}
 
-	// Processing joblarni Redis queuega qo'shish
+	// Fayli hali yuklanmagan video (presigned sessiya) MarkUploaded da
+	// indekslanadi - tashlab ketilgan sessiyalar ro'yxatlarda ko'rinmaydi
+	if status != "uploading" {
+		s.indexVideo(ctx, video)
+	}
+
+	return video, nil
+}
+
+// indexVideo videoni kanal ro'yxati va qidiruv indeksiga qo'shadi
+func (s *VideoService) indexVideo(ctx context.Context, video *models.Video) {
+	// Kanal sahifasi uchun
+	s.syncChannelEntry(ctx, video)
+
+	// Qidiruv indeksiga qo'shish
+	for _, keyword := range searchKeywords(video.Title) {
+		if err := s.repos.Search.Index(ctx, keyword, video); err != nil {
+			log.Printf("Search index xatosi (%s): %v", video.ID, err)
+		}
+	}
+}
+
+// searchKeywords sarlavhani qidiruv kalit so'zlariga ajratadi
+func searchKeywords(title string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+
+	for _, word := range strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}) {
+		if len([]rune(word)) < 2 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+
+	return keywords
+}
+
+// Processing joblarni navbatga qo'shish
+func (s *VideoService) enqueueProcessingJobs(ctx context.Context, videoID gocql.UUID) {
 	jobs := []models.ProcessingJob{
+		{
+			JobID:     gocql.TimeUUID(),
+			VideoID:   videoID,
+			JobType:   "probe",
+			Status:    "pending",
+			Priority:  0,
+			CreatedAt: time.Now(),
+		},
 		{
 			JobID:     gocql.TimeUUID(),
 			VideoID:   videoID,
@@ -85,39 +195,83 @@ func (s *VideoService) UploadVideo(ctx context.Context, title, description, user
 			Priority:  2,
 			CreatedAt: time.Now(),
 		},
+		{
+			JobID:     gocql.TimeUUID(),
+			VideoID:   videoID,
+			JobType:   "extract_audio",
+			Status:    "pending",
+			Priority:  3,
+			CreatedAt: time.Now(),
+		},
+		{
+			JobID:     gocql.TimeUUID(),
+			VideoID:   videoID,
+			JobType:   "sprites",
+			Status:    "pending",
+			Priority:  4,
+			CreatedAt: time.Now(),
+		},
 	}
 
 	for _, job := range jobs {
+		job.UpdatedAt = job.CreatedAt
+		if err := s.repos.Jobs.Save(ctx, &job); err != nil {
+			log.Printf("Job holati saqlanmadi (%s): %v", job.JobID, err)
+		}
+
+		q, err := s.jobs.For(job.JobType)
+		if err != nil {
+			log.Printf("Job navbatga qo'shilmadi (%s): %v", job.JobID, err)
+			continue
+		}
+
 		jobData, _ := json.Marshal(job)
-		s.redis.RPush(ctx, "processing_queue", jobData)
+		if err := q.Enqueue(ctx, jobData); err != nil {
+			log.Printf("Job navbatga qo'shilmadi (%s): %v", job.JobID, err)
+		}
 	}
-
-	return video, nil
 }
 
-func (s *VideoService) GetVideos(ctx context.Context, limit int) ([]models.Video, error) {
-	query := "SELECT id, title, description, username, thumbnail_url, video_url, duration, created_at FROM videos LIMIT ?"
-	iter := s.cassandra.Query(query, limit).Iter()
+// GetVideos videolar ro'yxatining bitta sahifasi va keyingi sahifa kursori
+func (s *VideoService) GetVideos(ctx context.Context, cursor string, limit int) ([]models.Video, string, error) {
+	state, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
 
-	var videos []models.Video
-	var video models.Video
+	videos, next, err := s.repos.Videos.List(ctx, repository.PageRequest{Size: limit, State: state})
+	if err != nil {
+		return nil, "", pageError(err)
+	}
 
-	for iter.Scan(&video.ID, &video.Title, &video.Description, &video.Username,
-		&video.ThumbnailURL, &video.VideoURL, &video.Duration, &video.CreatedAt) {
+	applyCounters(ctx, s.repos.Counters, videos)
+	for i := range videos {
+		rewriteLegacyURLs(&videos[i])
+	}
+	return videos, encodeCursor(next), nil
+}
 
-		// Views counterini olish
-		viewQuery := "SELECT views FROM videos WHERE id = ?"
-		s.cassandra.Query(viewQuery, video.ID).Scan(&video.Views)
+// applyCounters ro'yxatdagi videolarga video_counters qiymatlarini bitta so'rov
+// bilan yozadi. Hisoblagichlar o'qilmasa ro'yxat ularsiz qaytariladi.
+func applyCounters(ctx context.Context, counters repository.VideoCounterRepository, videos []models.Video) {
+	if len(videos) == 0 {
+		return
+	}
 
-		videos = append(videos, video)
-		video = models.Video{}
+	ids := make([]gocql.UUID, len(videos))
+	for i, video := range videos {
+		ids[i] = video.ID
 	}
 
-	if err := iter.Close(); err != nil {
-		return nil, err
+	values, err := counters.GetMany(ctx, ids)
+	if err != nil {
+		log.Printf("Hisoblagichlar o'qilmadi: %v", err)
+		return
 	}
 
-	return videos, nil
+	for i := range videos {
+		videos[i].ApplyCounters(values[videos[i].ID])
+	}
 }
 
 func (s *VideoService) GetVideo(ctx context.Context, videoID string) (*models.Video, error) {
@@ -126,26 +280,35 @@ func (s *VideoService) GetVideo(ctx context.Context, videoID string) (*models.Vi
 		return nil, fmt.Errorf("noto'g'ri video ID: %w", err)
 	}
 
-	var video models.Video
-	query := `SELECT id, title, description, username, file_name, file_size, 
-		duration, thumbnail_url, video_url, status, created_at, updated_at 
-		FROM videos WHERE id = ?`
-
-	err = s.cassandra.Query(query, id).Scan(
-		&video.ID, &video.Title, &video.Description, &video.Username,
-		&video.FileName, &video.FileSize, &video.Duration,
-		&video.ThumbnailURL, &video.VideoURL, &video.Status,
-		&video.CreatedAt, &video.UpdatedAt,
-	)
+	video, err := s.repos.Videos.Get(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("video topilmadi: %w", err)
 	}
 
-	// Views counterini olish
-	viewQuery := "SELECT views FROM videos WHERE id = ?"
-	s.cassandra.Query(viewQuery, video.ID).Scan(&video.Views)
+	counters, err := s.repos.Counters.Get(ctx, id)
+	if err != nil {
+		log.Printf("Hisoblagichlar o'qilmadi (%s): %v", id, err)
+	}
+	video.ApplyCounters(counters)
+	rewriteLegacyURLs(video)
+
+	return video, nil
+}
 
-	return &video, nil
+// rewriteLegacyURLs asset resolverdan oldin yozilgan /videos/... va
+// /thumbnails/... manzillarini joriy asset URL lariga aylantiradi (o'qishda;
+// bazadagi qiymatlar o'zgartirilmaydi)
+func rewriteLegacyURLs(video *models.Video) {
+	video.VideoURL = assets.RewriteLegacyURL(video.VideoURL)
+	video.ThumbnailURL = assets.RewriteLegacyURL(video.ThumbnailURL)
+	if len(video.QualityVersions) == 0 {
+		return
+	}
+	versions := make(map[string]string, len(video.QualityVersions))
+	for quality, url := range video.QualityVersions {
+		versions[quality] = assets.RewriteLegacyURL(url)
+	}
+	video.QualityVersions = versions
 }
 
 func (s *VideoService) IncrementView(ctx context.Context, videoID string) error {
@@ -158,49 +321,112 @@ func (s *VideoService) IncrementView(ctx context.Context, videoID string) error
 	s.redis.RPush(ctx, "view_queue", videoID)
 
 	// Cassandra counterini oshirish
-	query := "UPDATE videos SET views = views + 1 WHERE id = ?"
-	return s.cassandra.Query(query, id).Exec()
+	return s.repos.Counters.IncrementViews(ctx, id, 1)
 }
 
 func (s *VideoService) DeleteVideo(ctx context.Context, videoID string) error {
-	id, err := gocql.ParseUUID(videoID)
-	if err != nil {
-		return err
-	}
-
 	// Video ma'lumotlarini olish
 	video, err := s.GetVideo(ctx, videoID)
 	if err != nil {
 		return err
 	}
 
-	// MinIOdan fayllarni o'chirish
-	objectName := fmt.Sprintf("raw/%s-%s", video.ID.String(), video.FileName)
-	s.minio.RemoveObject(ctx, "videos-raw", objectName, minio.RemoveObjectOptions{})
+	// Storagedan fayllarni o'chirish
+	objectName := RawObjectName(video.ID, video.FileName)
+	s.store.Delete(ctx, s.buckets.Raw, objectName)
 
 	// Cassandradan o'chirish
-	query := "DELETE FROM videos WHERE id = ?"
-	return s.cassandra.Query(query, id).Exec()
+	if err := s.repos.VideosByUser.Remove(ctx, video.UserID, video.CreatedAt, video.ID); err != nil {
+		log.Printf("Kanal ro'yxatidan o'chirilmadi (%s): %v", video.ID, err)
+	}
+	for _, keyword := range searchKeywords(video.Title) {
+		if err := s.repos.Search.Remove(ctx, keyword, video.CreatedAt, video.ID); err != nil {
+			log.Printf("Search indeksidan o'chirilmadi (%s, %s): %v", video.ID, keyword, err)
+		}
+	}
+	if err := s.repos.Counters.Delete(ctx, video.ID); err != nil {
+		log.Printf("Hisoblagichlar o'chirilmadi (%s): %v", video.ID, err)
+	}
+	return s.repos.Videos.Delete(ctx, video.ID)
 }
 
-func (s *VideoService) UpdateVideoStatus(ctx context.Context, videoID gocql.UUID, status, videoURL, thumbnailURL string) error {
-	query := `UPDATE videos SET status = ?, video_url = ?, thumbnail_url = ?, 
-		updated_at = ? WHERE id = ?`
-	return s.cassandra.Query(query, status, videoURL, thumbnailURL, time.Now(), videoID).Exec()
+// MarkReady transcode tugagach videoni "ready" qiladi va sifat URLlarini saqlaydi
+func (s *VideoService) MarkReady(ctx context.Context, videoID gocql.UUID, videoURL string, qualityVersions map[string]string) error {
+	return s.repos.Videos.MarkReady(ctx, videoID, videoURL, qualityVersions)
 }
 
-func (s *VideoService) SearchVideos(ctx context.Context, keyword string, limit int) ([]models.Video, error) {
-	// Simple search (production uchun Elasticsearch kerak)
-	query := "SELECT video_id, title, thumbnail_url FROM video_search WHERE keyword = ? LIMIT ?"
-	iter := s.cassandra.Query(query, keyword, limit).Iter()
+// MarkFailed videoni xato xabari bilan "failed" holatiga o'tkazadi
+func (s *VideoService) MarkFailed(ctx context.Context, videoID gocql.UUID, errorMessage string) error {
+	return s.repos.Videos.MarkFailed(ctx, videoID, errorMessage)
+}
 
-	var videos []models.Video
-	var video models.Video
+// SetMediaInfo manba fayl haqidagi ffprobe natijasini saqlaydi
+func (s *VideoService) SetMediaInfo(ctx context.Context, videoID gocql.UUID, info *models.MediaInfo) error {
+	return s.repos.Videos.SetMediaInfo(ctx, videoID, info)
+}
 
-	for iter.Scan(&video.ID, &video.Title, &video.ThumbnailURL) {
-		videos = append(videos, video)
-		video = models.Video{}
+// SetAudioVersions tayyor audio-only renditionlarni saqlaydi
+func (s *VideoService) SetAudioVersions(ctx context.Context, videoID gocql.UUID, versions map[string]string) error {
+	return s.repos.Videos.SetAudioVersions(ctx, videoID, versions)
+}
+
+// SetPreviewTrack seek bar preview (WebVTT) track manzilini saqlaydi
+func (s *VideoService) SetPreviewTrack(ctx context.Context, videoID gocql.UUID, trackURL string) error {
+	return s.repos.Videos.SetPreviewTrack(ctx, videoID, trackURL)
+}
+
+// SetGeneratedThumbnails thumbnail job yaratgan variantlarni saqlaydi. Eng
+// yaxshi variant asosiy thumbnail bo'ladi, agar ega o'zinikini qo'ymagan
+// bo'lsa. Job ishlayotganda ega thumbnail qo'yishi mumkin, shuning uchun yozuv
+// shartli: asosiy thumbnail o'qilgandan beri o'zgargan bo'lsa video qayta
+// o'qiladi va ega tanlovi saqlanadi.
+func (s *VideoService) SetGeneratedThumbnails(ctx context.Context, videoID gocql.UUID, candidates []models.ThumbnailCandidate) error {
+	for attempt := 0; attempt < 3; attempt++ {
+		video, err := s.repos.Videos.Get(ctx, videoID)
+		if err != nil {
+			return err
+		}
+
+		thumbnailURL := video.ThumbnailURL
+		for i := range candidates {
+			if video.ThumbnailURL != "" {
+				candidates[i].Selected = false
+			} else if candidates[i].Selected {
+				thumbnailURL = candidates[i].URL
+			}
+		}
+
+		applied, err := s.repos.Videos.SetThumbnailIf(ctx, videoID, thumbnailURL, candidates, video.ThumbnailURL)
+		if err != nil {
+			return err
+		}
+		if applied {
+			video.ThumbnailURL = thumbnailURL
+			video.ThumbnailCandidates = candidates
+			s.syncChannelEntry(ctx, video)
+			return nil
+		}
 	}
 
-	return videos, iter.Close()
+	return fmt.Errorf("thumbnail bir vaqtda o'zgartirilmoqda: %s", videoID)
+}
+
+func (s *VideoService) SearchVideos(ctx context.Context, keyword, cursor string, limit int) ([]models.Video, string, error) {
+	state, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Simple search (production uchun Elasticsearch kerak)
+	page := repository.PageRequest{Size: limit, State: state}
+	videos, next, err := s.repos.Search.Search(ctx, strings.ToLower(strings.TrimSpace(keyword)), page)
+	if err != nil {
+		return nil, "", pageError(err)
+	}
+
+	applyCounters(ctx, s.repos.Counters, videos)
+	for i := range videos {
+		rewriteLegacyURLs(&videos[i])
+	}
+	return videos, encodeCursor(next), nil
 }
@@ -0,0 +1,393 @@
+// services/video_service_test.go
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/queue"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+	"github.com/gocql/gocql"
+	"github.com/redis/go-redis/v9"
+)
+
+var testBuckets = config.Buckets{
+	Videos:     "videos",
+	Raw:        "videos-raw",
+	Processed:  "videos-processed",
+	Thumbnails: "thumbnails",
+}
+
+// testEnv xotiradagi repositorylar va lokal storage ustidagi servislar
+type testEnv struct {
+	repos     repository.Repositories
+	store     *storage.LocalStore
+	videos    *VideoService
+	analytics *AnalyticsService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	store, err := storage.NewLocalStore(t.TempDir(), testBuckets.All(), "http://localhost", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ulanib bo'lmaydigan Redis: testlar Cassandra/Redissiz ishlaydi, Redisga
+	// tegadigan yo'llar (view queue) bu yerda tekshirilmaydi
+	rdb := redis.NewClient(&redis.Options{
+		MaxRetries: -1,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("testda redis yo'q")
+		},
+	})
+	t.Cleanup(func() { rdb.Close() })
+
+	repos := repository.NewMemoryRepositories()
+	// Navbatsiz: processing joblari faqat repositoryda saqlanadi
+	jobs := queue.Queues{}
+
+	return &testEnv{
+		repos:     repos,
+		store:     store,
+		videos:    NewVideoService(repos, store, testBuckets, rdb, jobs),
+		analytics: NewAnalyticsService(repos),
+	}
+}
+
+// testUserID testlardagi standart kanal egasi
+var testUserID = gocql.TimeUUID()
+
+func (e *testEnv) upload(t *testing.T, title string) string {
+	t.Helper()
+	return e.uploadAs(t, testUserID, title)
+}
+
+func (e *testEnv) uploadAs(t *testing.T, userID gocql.UUID, title string) string {
+	t.Helper()
+
+	video, err := e.videos.UploadVideo(context.Background(), title, "tavsif", userID.String(), "tester",
+		strings.NewReader("fake video"), int64(len("fake video")), "clip.mp4")
+	if err != nil {
+		t.Fatalf("UploadVideo: %v", err)
+	}
+	return video.ID.String()
+}
+
+func TestUploadAndGetVideo(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	id := env.upload(t, "Go darslari")
+
+	video, err := env.videos.GetVideo(ctx, id)
+	if err != nil {
+		t.Fatalf("GetVideo: %v", err)
+	}
+	if video.Title != "Go darslari" || video.Username != "tester" {
+		t.Errorf("video = %q/%q, kutilgan Go darslari/tester", video.Title, video.Username)
+	}
+	if video.Status != "processing" {
+		t.Errorf("status = %q, kutilgan processing", video.Status)
+	}
+
+	info, err := env.store.Stat(ctx, testBuckets.Raw, RawObjectName(video.ID, "clip.mp4"))
+	if err != nil {
+		t.Fatalf("raw obyekt topilmadi: %v", err)
+	}
+	if info.Size != int64(len("fake video")) {
+		t.Errorf("raw obyekt hajmi = %d", info.Size)
+	}
+
+	channel, _, err := env.videos.ListUserVideos(ctx, video.UserID.String(), "", 10)
+	if err != nil {
+		t.Fatalf("ListUserVideos: %v", err)
+	}
+	if len(channel) != 1 || channel[0].ID != video.ID {
+		t.Errorf("kanal ro'yxati = %v", channel)
+	}
+}
+
+// Bir foydalanuvchining barcha yuklamalari bitta kanal ro'yxatida
+func TestUserVideosListsAllUploads(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	owner := gocql.TimeUUID()
+	first := env.uploadAs(t, owner, "Birinchi video")
+	second := env.uploadAs(t, owner, "Ikkinchi video")
+	env.uploadAs(t, gocql.TimeUUID(), "Boshqa kanal")
+
+	channel, _, err := env.videos.ListUserVideos(ctx, owner.String(), "", 10)
+	if err != nil {
+		t.Fatalf("ListUserVideos: %v", err)
+	}
+	if len(channel) != 2 {
+		t.Fatalf("kanalda %d ta video, kutilgan 2: %v", len(channel), channel)
+	}
+	got := map[string]bool{channel[0].ID.String(): true, channel[1].ID.String(): true}
+	if !got[first] || !got[second] {
+		t.Errorf("kanal ro'yxati = %v", channel)
+	}
+	for _, video := range channel {
+		if video.UserID != owner {
+			t.Errorf("video %s egasi %s, kutilgan %s", video.ID, video.UserID, owner)
+		}
+	}
+}
+
+// Presigned sessiya video yozuvi fayl yuklanmaguncha kanal va qidiruvda ko'rinmaydi
+func TestPendingVideoIndexedOnUpload(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	owner := gocql.TimeUUID()
+	video, err := env.videos.CreatePendingVideo(ctx, gocql.TimeUUID(), "Kutilayotgan video", "tavsif", owner, "tester", 10, "clip.mp4")
+	if err != nil {
+		t.Fatalf("CreatePendingVideo: %v", err)
+	}
+
+	visible := func() (channel, search int) {
+		t.Helper()
+		videos, _, err := env.videos.ListUserVideos(ctx, owner.String(), "", 10)
+		if err != nil {
+			t.Fatalf("ListUserVideos: %v", err)
+		}
+		results, _, err := env.videos.SearchVideos(ctx, "kutilayotgan", "", 10)
+		if err != nil {
+			t.Fatalf("SearchVideos: %v", err)
+		}
+		return len(videos), len(results)
+	}
+
+	if channel, search := visible(); channel != 0 || search != 0 {
+		t.Errorf("yuklanmagan video ko'rinadi: kanal %d, qidiruv %d", channel, search)
+	}
+
+	if err := env.videos.MarkUploaded(ctx, video.ID); err != nil {
+		t.Fatalf("MarkUploaded: %v", err)
+	}
+	if channel, search := visible(); channel != 1 || search != 1 {
+		t.Errorf("yuklangan video: kanal %d, qidiruv %d, kutilgan 1/1", channel, search)
+	}
+}
+
+func TestUploadInvalidUserID(t *testing.T) {
+	env := newTestEnv(t)
+
+	_, err := env.videos.UploadVideo(context.Background(), "Title", "tavsif", "not-a-uuid", "tester",
+		strings.NewReader("fake video"), int64(len("fake video")), "clip.mp4")
+	if !errors.Is(err, ErrInvalidUserID) {
+		t.Fatalf("err = %v, kutilgan ErrInvalidUserID", err)
+	}
+}
+
+func TestGetVideoInvalidID(t *testing.T) {
+	env := newTestEnv(t)
+
+	if _, err := env.videos.GetVideo(context.Background(), "not-a-uuid"); err == nil {
+		t.Fatal("noto'g'ri ID uchun xato kutilgan")
+	}
+}
+
+func TestDeleteVideo(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	id := env.upload(t, "O'chiriladigan video")
+	video, err := env.videos.GetVideo(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.videos.DeleteVideo(ctx, id); err != nil {
+		t.Fatalf("DeleteVideo: %v", err)
+	}
+
+	if _, err := env.videos.GetVideo(ctx, id); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("o'chirilgan video: err = %v, kutilgan ErrNotFound", err)
+	}
+	if _, err := env.store.Stat(ctx, testBuckets.Raw, RawObjectName(video.ID, "clip.mp4")); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("raw obyekt o'chirilmagan: %v", err)
+	}
+
+	channel, _, err := env.videos.ListUserVideos(ctx, video.UserID.String(), "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channel) != 0 {
+		t.Errorf("kanal ro'yxatida qoldi: %v", channel)
+	}
+
+	for _, keyword := range []string{"o'chiriladigan", "video"} {
+		results, _, err := env.videos.SearchVideos(ctx, keyword, "", 10)
+		if err != nil {
+			t.Fatalf("SearchVideos(%q): %v", keyword, err)
+		}
+		if len(results) != 0 {
+			t.Errorf("qidiruvda qoldi (%q): %v", keyword, results)
+		}
+	}
+}
+
+func TestSearchVideos(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	env.upload(t, "Golang concurrency")
+	env.upload(t, "Golang generics")
+	env.upload(t, "Rust ownership")
+
+	results, _, err := env.videos.SearchVideos(ctx, "  GOLANG ", "", 10)
+	if err != nil {
+		t.Fatalf("SearchVideos: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("natijalar soni = %d, kutilgan 2", len(results))
+	}
+	for _, video := range results {
+		if !strings.Contains(video.Title, "Golang") {
+			t.Errorf("kutilmagan natija: %q", video.Title)
+		}
+	}
+
+	// Sahifalash: birinchi sahifa bitta natija va keyingi kursor
+	page, next, err := env.videos.SearchVideos(ctx, "golang", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || next == "" {
+		t.Fatalf("birinchi sahifa = %d ta, next = %q", len(page), next)
+	}
+	rest, next, err := env.videos.SearchVideos(ctx, "golang", next, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 1 || rest[0].ID == page[0].ID || next != "" {
+		t.Errorf("ikkinchi sahifa = %v, next = %q", rest, next)
+	}
+
+	if _, _, err := env.videos.SearchVideos(ctx, "golang", "%%%", 1); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("noto'g'ri kursor: err = %v", err)
+	}
+}
+
+func TestTrendingVideos(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	low := env.upload(t, "Kam ko'rilgan")
+	high := env.upload(t, "Ko'p ko'rilgan")
+	mid := env.upload(t, "O'rtacha")
+
+	// Hisoblagichlar to'g'ridan-to'g'ri (IncrementView Redis view queue ga ham yozadi)
+	views := map[string]int64{low: 1, high: 5, mid: 3}
+	for id, n := range views {
+		uuid, _ := gocql.ParseUUID(id)
+		if err := env.repos.Counters.IncrementViews(ctx, uuid, n); err != nil {
+			t.Fatalf("IncrementViews: %v", err)
+		}
+	}
+
+	if err := env.analytics.UpdateTrendingVideos(ctx); err != nil {
+		t.Fatalf("UpdateTrendingVideos: %v", err)
+	}
+
+	trending, _, err := env.analytics.GetTrendingVideos(ctx, "", 10)
+	if err != nil {
+		t.Fatalf("GetTrendingVideos: %v", err)
+	}
+	if len(trending) != 3 {
+		t.Fatalf("trending soni = %d, kutilgan 3", len(trending))
+	}
+
+	want := []string{high, mid, low}
+	for i, video := range trending {
+		if video.ID.String() != want[i] {
+			t.Errorf("trending[%d] = %s, kutilgan %s", i, video.ID, want[i])
+		}
+		if video.Views != views[want[i]] {
+			t.Errorf("trending[%d].Views = %d, kutilgan %d", i, video.Views, views[want[i]])
+		}
+	}
+}
+
+func TestGeneratedThumbnailsKeepOwnerChoice(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	id := env.upload(t, "Thumbnail")
+	video, err := env.videos.GetVideo(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Job ishlayotganda ega o'z thumbnailini qo'ygan
+	custom := "/api/videos/" + id + "/thumbnails/custom-1.jpg"
+	if err := env.repos.Videos.SetThumbnail(ctx, video.ID, custom, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates := []models.ThumbnailCandidate{
+		{URL: "/api/videos/" + id + "/thumbnails/candidate-0.jpg"},
+		{URL: "/api/videos/" + id + "/thumbnails/candidate-1.jpg", Selected: true},
+	}
+	if err := env.videos.SetGeneratedThumbnails(ctx, video.ID, candidates); err != nil {
+		t.Fatalf("SetGeneratedThumbnails: %v", err)
+	}
+
+	video, err = env.videos.GetVideo(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if video.ThumbnailURL != custom {
+		t.Errorf("thumbnail = %q, kutilgan %q", video.ThumbnailURL, custom)
+	}
+	if len(video.ThumbnailCandidates) != 2 {
+		t.Fatalf("variantlar soni = %d", len(video.ThumbnailCandidates))
+	}
+	for _, c := range video.ThumbnailCandidates {
+		if c.Selected {
+			t.Errorf("ega thumbnaili bor, lekin %s tanlangan", c.URL)
+		}
+	}
+}
+
+func TestLegacyURLsRewrittenOnRead(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	id := env.upload(t, "Eski video")
+	video, err := env.videos.GetVideo(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Asset resolverdan oldingi format
+	legacy := map[string]string{"720p": "/videos/" + id + "/720p"}
+	if err := env.repos.Videos.MarkReady(ctx, video.ID, legacy["720p"], legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.repos.Videos.SetThumbnail(ctx, video.ID, "/thumbnails/"+id+"/thumbnail.jpg", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	video, err = env.videos.GetVideo(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendition := "/api/videos/" + id + "/renditions/720p.mp4"
+	if video.VideoURL != rendition || video.QualityVersions["720p"] != rendition {
+		t.Errorf("video URL = %q, quality = %q, kutilgan %q", video.VideoURL, video.QualityVersions["720p"], rendition)
+	}
+	if want := "/api/videos/" + id + "/thumbnails/thumbnail.jpg"; video.ThumbnailURL != want {
+		t.Errorf("thumbnail = %q, kutilgan %q", video.ThumbnailURL, want)
+	}
+}
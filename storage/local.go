@@ -0,0 +1,304 @@
+// storage/local.go
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocalStore - lokal disk backend (development va CI uchun).
+// Obyektlar <root>/<bucket>/<key> da, Content-Type esa <root>/.meta ostida saqlanadi.
+// Presigned URLlar HMAC bilan imzolanib servisning /storage marshrutiga yo'naltiriladi.
+type LocalStore struct {
+	root       string
+	publicURL  string
+	signingKey []byte
+}
+
+type localMeta struct {
+	ContentType string `json:"content_type"`
+	Bucket      string `json:"bucket,omitempty"`
+	Key         string `json:"key,omitempty"`
+}
+
+// NewLocalStore signingKey bo'sh bo'lsa tasodifiy kalit yaratadi (URLlar
+// faqat shu jarayon ishlayotganda amal qiladi)
+func NewLocalStore(root string, buckets []string, publicURL string, signingKey []byte) (*LocalStore, error) {
+	for _, bucket := range buckets {
+		if err := os.MkdirAll(filepath.Join(root, bucket), 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(signingKey) == 0 {
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LocalStore{
+		root:       root,
+		publicURL:  strings.TrimSuffix(publicURL, "/"),
+		signingKey: signingKey,
+	}, nil
+}
+
+// objectPath key ni root ichida qoladigan fayl yo'liga aylantiradi
+func (s *LocalStore) objectPath(bucket, key string) string {
+	return filepath.Join(s.root, bucket, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (s *LocalStore) metaPath(bucket, key string) string {
+	return filepath.Join(s.root, ".meta", bucket, filepath.FromSlash(path.Clean("/"+key))+".json")
+}
+
+func (s *LocalStore) uploadDir(uploadID string) string {
+	return filepath.Join(s.root, ".uploads", filepath.Base(uploadID))
+}
+
+func (s *LocalStore) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	if err := writeFileAtomic(s.objectPath(bucket, key), r); err != nil {
+		return err
+	}
+	return writeJSON(s.metaPath(bucket, key), localMeta{ContentType: contentType})
+}
+
+func (s *LocalStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.objectPath(bucket, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *LocalStore) GetRange(ctx context.Context, bucket, key string, offset, length int64) (io.ReadCloser, error) {
+	file, err := os.Open(s.objectPath(bucket, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(file, offset, length), file}, nil
+}
+
+func (s *LocalStore) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	fi, err := os.Stat(s.objectPath(bucket, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if fi.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+
+	var meta localMeta
+	readJSON(s.metaPath(bucket, key), &meta)
+
+	return ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ContentType:  meta.ContentType,
+		ETag:         fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size()),
+		LastModified: fi.ModTime(),
+	}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, bucket, key string) error {
+	os.Remove(s.metaPath(bucket, key))
+	err := os.Remove(s.objectPath(bucket, key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStore) List(ctx context.Context, bucket, prefix string) ([]ObjectInfo, error) {
+	bucketRoot := filepath.Join(s.root, bucket)
+
+	var objects []ObjectInfo
+	err := filepath.WalkDir(bucketRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(bucketRoot, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := s.Stat(ctx, bucket, key)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, info)
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	return objects, err
+}
+
+func (s *LocalStore) NewMultipartUpload(ctx context.Context, bucket, key, contentType string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(s.uploadDir(uploadID), 0o755); err != nil {
+		return "", err
+	}
+	meta := localMeta{ContentType: contentType, Bucket: bucket, Key: key}
+	if err := writeJSON(filepath.Join(s.uploadDir(uploadID), "upload.json"), meta); err != nil {
+		return "", err
+	}
+
+	return uploadID, nil
+}
+
+func (s *LocalStore) UploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int, r io.Reader, size int64) (Part, error) {
+	if _, err := os.Stat(s.uploadDir(uploadID)); err != nil {
+		return Part{}, ErrNotFound
+	}
+
+	hash := md5.New()
+	partPath := filepath.Join(s.uploadDir(uploadID), strconv.Itoa(partNumber))
+	if err := writeFileAtomic(partPath, io.TeeReader(r, hash)); err != nil {
+		return Part{}, err
+	}
+
+	return Part{PartNumber: partNumber, ETag: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+func (s *LocalStore) ListParts(ctx context.Context, bucket, key, uploadID string) ([]Part, error) {
+	entries, err := os.ReadDir(s.uploadDir(uploadID))
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var parts []Part
+	for _, entry := range entries {
+		n, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		parts = append(parts, Part{PartNumber: n})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	return parts, nil
+}
+
+func (s *LocalStore) CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []Part) error {
+	dir := s.uploadDir(uploadID)
+
+	var meta localMeta
+	if err := readJSON(filepath.Join(dir, "upload.json"), &meta); err != nil {
+		return ErrNotFound
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	pr, pw := io.Pipe()
+	go func() {
+		for _, p := range parts {
+			file, err := os.Open(filepath.Join(dir, strconv.Itoa(p.PartNumber)))
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("part %d: %w", p.PartNumber, err))
+				return
+			}
+			_, err = io.Copy(pw, file)
+			file.Close()
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.Close()
+	}()
+
+	if err := s.Put(ctx, bucket, key, pr, -1, meta.ContentType); err != nil {
+		pr.CloseWithError(err)
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func (s *LocalStore) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	dir := s.uploadDir(uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		return ErrNotFound
+	}
+	return os.RemoveAll(dir)
+}
+
+// writeFileAtomic vaqtinchalik faylga yozib, keyin rename qiladi
+func writeFileAtomic(dst string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dst)
+}
+
+func writeJSON(dst string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dst, bytes.NewReader(data))
+}
+
+func readJSON(src string, v any) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
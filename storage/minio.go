@@ -0,0 +1,173 @@
+// storage/minio.go
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MinIOStore - MinIO (S3) backend
+type MinIOStore struct {
+	client *minio.Client
+	core   minio.Core
+}
+
+func NewMinIOStore(client *minio.Client) *MinIOStore {
+	return &MinIOStore{
+		client: client,
+		core:   minio.Core{Client: client},
+	}
+}
+
+func (s *MinIOStore) Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return mapError(err)
+}
+
+func (s *MinIOStore) Get(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	return s.getObject(ctx, bucket, key, minio.GetObjectOptions{})
+}
+
+func (s *MinIOStore) GetRange(ctx context.Context, bucket, key string, offset, length int64) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(offset, offset+length-1); err != nil {
+		return nil, err
+	}
+	return s.getObject(ctx, bucket, key, opts)
+}
+
+// getObject GetObject lazy: so'rov birinchi o'qishda yuboriladi va NoSuchKey
+// ham shunda chiqadi. Stat so'rovni darhol bajaradi, shuning uchun yo'q obyekt
+// boshqa backendlardagidek shu yerning o'zida ErrNotFound qaytaradi.
+func (s *MinIOStore) getObject(ctx context.Context, bucket, key string, opts minio.GetObjectOptions) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, bucket, key, opts)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, mapError(err)
+	}
+	return object, nil
+}
+
+func (s *MinIOStore) Stat(ctx context.Context, bucket, key string) (ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, mapError(err)
+	}
+	return toObjectInfo(info), nil
+}
+
+func (s *MinIOStore) Delete(ctx context.Context, bucket, key string) error {
+	return mapError(s.client.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (s *MinIOStore) List(ctx context.Context, bucket, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	for info := range s.client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, mapError(info.Err)
+		}
+		objects = append(objects, toObjectInfo(info))
+	}
+	return objects, nil
+}
+
+func (s *MinIOStore) PresignGet(ctx context.Context, bucket, key string, expires time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, bucket, key, expires, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *MinIOStore) NewMultipartUpload(ctx context.Context, bucket, key, contentType string) (string, error) {
+	return s.core.NewMultipartUpload(ctx, bucket, key, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+}
+
+func (s *MinIOStore) UploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int, r io.Reader, size int64) (Part, error) {
+	part, err := s.core.PutObjectPart(ctx, bucket, key, uploadID, partNumber, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return Part{}, mapError(err)
+	}
+	return Part{PartNumber: part.PartNumber, ETag: part.ETag}, nil
+}
+
+func (s *MinIOStore) ListParts(ctx context.Context, bucket, key, uploadID string) ([]Part, error) {
+	var parts []Part
+
+	marker := 0
+	for {
+		result, err := s.core.ListObjectParts(ctx, bucket, key, uploadID, marker, 1000)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		for _, p := range result.ObjectParts {
+			parts = append(parts, Part{PartNumber: p.PartNumber, ETag: p.ETag})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	return parts, nil
+}
+
+func (s *MinIOStore) CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []Part) error {
+	completeParts := make([]minio.CompletePart, len(parts))
+	for i, p := range parts {
+		completeParts[i] = minio.CompletePart{PartNumber: p.PartNumber, ETag: p.ETag}
+	}
+
+	_, err := s.core.CompleteMultipartUpload(ctx, bucket, key, uploadID, completeParts, minio.PutObjectOptions{})
+	return mapError(err)
+}
+
+func (s *MinIOStore) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	return mapError(s.core.AbortMultipartUpload(ctx, bucket, key, uploadID))
+}
+
+func (s *MinIOStore) PresignUploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int, expires time.Duration) (string, error) {
+	params := url.Values{}
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	params.Set("uploadId", uploadID)
+
+	u, err := s.client.Presign(ctx, "PUT", bucket, key, expires, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func toObjectInfo(info minio.ObjectInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:          info.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+	}
+}
+
+// mapError MinIO "topilmadi" xatolarini ErrNotFound ga aylantiradi
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchUpload", "NoSuchBucket":
+		return ErrNotFound
+	}
+	return err
+}
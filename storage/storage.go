@@ -0,0 +1,71 @@
+// storage/storage.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/Coding-for-Machine/Videos-Service/database"
+)
+
+var (
+	ErrNotFound     = errors.New("obyekt topilmadi")
+	ErrNotSupported = errors.New("storage backend bu amalni qo'llab-quvvatlamaydi")
+)
+
+// ObjectInfo - obyekt haqida ma'lumot
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// Part - multipart uploadning yuklangan qismi
+type Part struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// ObjectStore - fayl saqlash interfeysi (MinIO yoki lokal disk)
+type ObjectStore interface {
+	Put(ctx context.Context, bucket, key string, r io.Reader, size int64, contentType string) error
+	// Get yo'q obyekt uchun o'qishdan oldin, shu chaqiruvning o'zida ErrNotFound qaytaradi
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	// GetRange [offset, offset+length) oralig'ini o'qiydi
+	GetRange(ctx context.Context, bucket, key string, offset, length int64) (io.ReadCloser, error)
+	Stat(ctx context.Context, bucket, key string) (ObjectInfo, error)
+	Delete(ctx context.Context, bucket, key string) error
+	List(ctx context.Context, bucket, prefix string) ([]ObjectInfo, error)
+	PresignGet(ctx context.Context, bucket, key string, expires time.Duration) (string, error)
+
+	// Multipart upload (tus va presigned uploadlar uchun)
+	NewMultipartUpload(ctx context.Context, bucket, key, contentType string) (string, error)
+	UploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int, r io.Reader, size int64) (Part, error)
+	ListParts(ctx context.Context, bucket, key, uploadID string) ([]Part, error)
+	CompleteMultipartUpload(ctx context.Context, bucket, key, uploadID string, parts []Part) error
+	AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error
+	PresignUploadPart(ctx context.Context, bucket, key, uploadID string, partNumber int, expires time.Duration) (string, error)
+}
+
+// New konfiguratsiya bo'yicha storage backendni yaratadi
+func New(cfg *config.Config) (ObjectStore, error) {
+	switch cfg.Storage.Backend {
+	case "local":
+		return NewLocalStore(cfg.Storage.LocalPath, cfg.Storage.Buckets.All(),
+			cfg.Storage.LocalPublicURL, []byte(cfg.Storage.LocalSigningKey))
+	case "minio", "":
+		client, err := database.NewMinIOClient(cfg.MinIO, cfg.Storage.Buckets.All())
+		if err != nil {
+			return nil, err
+		}
+		return NewMinIOStore(client), nil
+	default:
+		return nil, fmt.Errorf("noma'lum storage backend: %s", cfg.Storage.Backend)
+	}
+}
@@ -0,0 +1,204 @@
+// transcoder/fake.go
+package transcoder
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+)
+
+// Fake - ffmpeg o'rnatilmagan muhit (testlar, lokal ishlab chiqish) uchun transcoder.
+// Inputni o'qimaydi; chiqish fayllarini deterministik placeholder bilan to'ldiradi
+// va pipeline (storage, playlistlar, statuslar) odatdagidek ishlashi mumkin.
+type Fake struct {
+	// Info - Probe qaytaradigan natija
+	Info *models.MediaInfo
+	// Errors - metod nomi bo'yicha qaytariladigan xato (xatolarni sinash uchun)
+	Errors map[string]error
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// NewFake 10 soniyalik 1080p30 video (stereo AAC) deb javob beruvchi transcoder
+func NewFake() *Fake {
+	return &Fake{
+		Info: &models.MediaInfo{
+			Container: "mov,mp4,m4a,3gp,3g2,mj2",
+			Duration:  10,
+			Bitrate:   6_000_000,
+			Size:      7_500_000,
+			Video: &models.VideoStreamInfo{
+				Codec:       "h264",
+				Profile:     "High",
+				PixelFormat: "yuv420p",
+				Width:       1920,
+				Height:      1080,
+				FrameRate:   30,
+				Bitrate:     5_800_000,
+			},
+			Audio: &models.AudioStreamInfo{
+				Codec:      "aac",
+				Channels:   2,
+				SampleRate: 48000,
+				Bitrate:    192_000,
+			},
+		},
+		Errors: make(map[string]error),
+	}
+}
+
+// Calls chaqirilgan metodlar ro'yxati (tartib bilan)
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// record chaqiruvni yozadi va shu metod uchun sozlangan xatoni qaytaradi
+func (f *Fake) record(ctx context.Context, method string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, method)
+	return f.Errors[method]
+}
+
+func (f *Fake) Probe(ctx context.Context, input string) (*models.MediaInfo, error) {
+	if err := f.record(ctx, "Probe"); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(input); err != nil {
+		return nil, err
+	}
+
+	info := *f.Info
+	return &info, nil
+}
+
+func (f *Fake) TranscodeRendition(ctx context.Context, input, output string, spec RenditionSpec, onProgress func(Progress)) error {
+	if err := f.record(ctx, "TranscodeRendition"); err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("fake mp4 %dx%d v=%dk a=%dk\n", spec.Width, spec.Height, spec.VideoBitrate, spec.AudioBitrate)
+	if err := os.WriteFile(output, []byte(content), 0o644); err != nil {
+		return err
+	}
+
+	if onProgress != nil {
+		duration := time.Duration(f.Info.Duration * float64(time.Second))
+		onProgress(Progress{OutTime: duration / 2, Speed: 1})
+		onProgress(Progress{OutTime: duration, Speed: 1, End: true})
+	}
+	return nil
+}
+
+// ExtractFrame haqiqiy JPEG yozadi; rangi vaqtga bog'liq (turli kadrlar turlicha)
+func (f *Fake) ExtractFrame(ctx context.Context, input, output string, at time.Duration, width, height int) error {
+	if err := f.record(ctx, "ExtractFrame"); err != nil {
+		return err
+	}
+
+	return writeFakeFrame(output, at, width, height)
+}
+
+// ExtractFrames davomiylik bo'yicha kerakli sondagi kadrlarni yozadi
+func (f *Fake) ExtractFrames(ctx context.Context, input, outDir string, interval time.Duration, width int) ([]string, error) {
+	if err := f.record(ctx, "ExtractFrames"); err != nil {
+		return nil, err
+	}
+
+	duration := time.Duration(f.Info.Duration * float64(time.Second))
+	count := int((duration + interval - 1) / interval)
+	if count < 1 {
+		count = 1
+	}
+
+	frames := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		output := filepath.Join(outDir, fmt.Sprintf("frame_%05d.jpg", i+1))
+		if err := writeFakeFrame(output, time.Duration(i)*interval, width, 0); err != nil {
+			return nil, err
+		}
+		frames = append(frames, output)
+	}
+	return frames, nil
+}
+
+// writeFakeFrame gradient JPEG yozadi; qizil kanal vaqtga bog'liq
+func writeFakeFrame(output string, at time.Duration, width, height int) error {
+	if height <= 0 {
+		height = width * 9 / 16
+	}
+	shade := uint8(int(at.Seconds()*25) % 256)
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: shade, G: uint8(x * 255 / width), B: uint8(y * 255 / height), A: 255})
+		}
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return jpeg.Encode(file, img, &jpeg.Options{Quality: 80})
+}
+
+func (f *Fake) ExtractAudio(ctx context.Context, input, output string, spec AudioSpec) error {
+	if err := f.record(ctx, "ExtractAudio"); err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("fake %s %dk %dch\n", spec.Codec, spec.Bitrate, spec.Channels)
+	return os.WriteFile(output, []byte(content), 0o644)
+}
+
+func (f *Fake) PackageHLS(ctx context.Context, input, outDir string, segmentTime int) error {
+	if err := f.record(ctx, "PackageHLS"); err != nil {
+		return err
+	}
+
+	playlist := fmt.Sprintf("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:%d\n#EXT-X-PLAYLIST-TYPE:VOD\n#EXTINF:%.3f,\nsegment_00000.ts\n#EXT-X-ENDLIST\n",
+		segmentTime, f.Info.Duration)
+	if err := os.WriteFile(filepath.Join(outDir, "index.m3u8"), []byte(playlist), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "segment_00000.ts"), []byte("fake ts\n"), 0o644)
+}
+
+func (f *Fake) PackageDASH(ctx context.Context, inputs []string, hasAudio bool, outDir string, segmentTime int) error {
+	if err := f.record(ctx, "PackageDASH"); err != nil {
+		return err
+	}
+
+	manifest := fmt.Sprintf("<?xml version=\"1.0\"?>\n<MPD type=\"static\" mediaPresentationDuration=\"PT%.3fS\" minBufferTime=\"PT%dS\"></MPD>\n",
+		f.Info.Duration, segmentTime)
+	if err := os.WriteFile(filepath.Join(outDir, "manifest.mpd"), []byte(manifest), 0o644); err != nil {
+		return err
+	}
+
+	for i := range inputs {
+		for _, name := range []string{fmt.Sprintf("init-%d.m4s", i), fmt.Sprintf("chunk-%d-00001.m4s", i)} {
+			if err := os.WriteFile(filepath.Join(outDir, name), []byte("fake m4s\n"), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+// transcoder/ffmpeg.go
+package transcoder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+)
+
+// FFmpeg - ffmpeg/ffprobe binarylari orqali ishlovchi transcoder.
+// Barcha jarayonlar ctx bilan bog'langan: job bekor qilinsa ffmpeg ham to'xtaydi.
+type FFmpeg struct {
+	ffmpegPath  string
+	ffprobePath string
+}
+
+func NewFFmpeg(ffmpegPath, ffprobePath string) *FFmpeg {
+	return &FFmpeg{
+		ffmpegPath:  ffmpegPath,
+		ffprobePath: ffprobePath,
+	}
+}
+
+// Probe lokal faylni ffprobe bilan tekshiradi
+func (f *FFmpeg) Probe(ctx context.Context, input string) (*models.MediaInfo, error) {
+	cmd := exec.CommandContext(ctx, f.ffprobePath,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		input,
+	)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe xatosi: %w", err)
+	}
+
+	return parseFFprobe(output)
+}
+
+// TranscodeRendition H.264 Main + AAC-LC MP4 yaratadi
+func (f *FFmpeg) TranscodeRendition(ctx context.Context, input, output string, spec RenditionSpec, onProgress func(Progress)) error {
+	args := []string{
+		"-i", input,
+		"-vf", spec.videoFilter(),
+		"-c:v", "libx264",
+		"-profile:v", "main",
+		"-level", spec.Level,
+		"-crf", "23",
+		"-preset", "medium",
+		"-maxrate", fmt.Sprintf("%dk", spec.VideoBitrate),
+		"-bufsize", fmt.Sprintf("%dk", 2*spec.VideoBitrate),
+	}
+	if spec.KeyframeInterval > 0 {
+		// HLS segmentlari bir xil joyda kesilishi uchun keyframelarni tekislash
+		args = append(args,
+			"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", spec.KeyframeInterval),
+			"-sc_threshold", "0",
+		)
+	}
+	args = append(args, spec.audioArgs()...)
+	args = append(args,
+		"-movflags", "+faststart",
+		"-y",
+		output,
+	)
+
+	return f.runWithProgress(ctx, args, onProgress)
+}
+
+// ExtractFrame bitta kadrni JPEG qilib saqlaydi
+func (f *FFmpeg) ExtractFrame(ctx context.Context, input, output string, at time.Duration, width, height int) error {
+	if height <= 0 {
+		height = -2 // aspect ratio saqlanadi, juft o'lcham
+	}
+
+	// -ss inputdan oldin: tez (keyframe bo'yicha) qidirish
+	return f.run(ctx, "ffmpeg kadr",
+		"-ss", formatSeconds(at),
+		"-i", input,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:%d", width, height),
+		"-q:v", "2",
+		"-y",
+		output,
+	)
+}
+
+// ExtractFrames fps filtri bilan kadrlarni bitta o'tishda ajratadi
+func (f *FFmpeg) ExtractFrames(ctx context.Context, input, outDir string, interval time.Duration, width int) ([]string, error) {
+	err := f.run(ctx, "ffmpeg kadrlar",
+		"-i", input,
+		"-an",
+		"-vf", fmt.Sprintf("fps=1/%s,scale=%d:-2", formatSeconds(interval), width),
+		"-q:v", "5",
+		"-y",
+		filepath.Join(outDir, "frame_%05d.jpg"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// %05d nomlari leksikografik tartibda ham to'g'ri
+	return filepath.Glob(filepath.Join(outDir, "frame_*.jpg"))
+}
+
+// ExtractAudio audio yo'lakni qayta kodlab alohida faylga yozadi
+func (f *FFmpeg) ExtractAudio(ctx context.Context, input, output string, spec AudioSpec) error {
+	codec := "aac"
+	if spec.Codec == "opus" {
+		codec = "libopus"
+	}
+
+	args := []string{
+		"-i", input,
+		"-vn",
+		"-c:a", codec,
+		"-b:a", fmt.Sprintf("%dk", spec.Bitrate),
+	}
+	if spec.Channels > 0 {
+		args = append(args, "-ac", strconv.Itoa(spec.Channels))
+	}
+	if filepath.Ext(output) == ".m4a" || filepath.Ext(output) == ".mp4" {
+		args = append(args, "-movflags", "+faststart")
+	}
+	args = append(args, "-y", output)
+
+	return f.run(ctx, "ffmpeg audio", args...)
+}
+
+// PackageHLS MP4 ni qayta kodlamasdan HLS segmentlariga bo'ladi
+func (f *FFmpeg) PackageHLS(ctx context.Context, input, outDir string, segmentTime int) error {
+	return f.run(ctx, "ffmpeg hls",
+		"-i", input,
+		"-c", "copy",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(segmentTime),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(outDir, "segment_%05d.ts"),
+		filepath.Join(outDir, "index.m3u8"),
+	)
+}
+
+// PackageDASH MP4 larni bitta MPD ostida fMP4 segmentlarga bo'ladi.
+// Audio oxirgi (eng yuqori sifatli) inputdan olinadi. Audiosiz manbada audio
+// AdaptationSet e'lon qilinmaydi - bo'sh to'plam playerlarni chalg'itadi.
+func (f *FFmpeg) PackageDASH(ctx context.Context, inputs []string, hasAudio bool, outDir string, segmentTime int) error {
+	var args []string
+	for _, input := range inputs {
+		args = append(args, "-i", input)
+	}
+	for i := range inputs {
+		args = append(args, "-map", fmt.Sprintf("%d:v", i))
+	}
+	adaptationSets := "id=0,streams=v"
+	if hasAudio {
+		args = append(args, "-map", fmt.Sprintf("%d:a", len(inputs)-1))
+		adaptationSets += " id=1,streams=a"
+	}
+	args = append(args,
+		"-c", "copy",
+		"-f", "dash",
+		"-seg_duration", strconv.Itoa(segmentTime),
+		"-use_template", "1",
+		"-use_timeline", "1",
+		"-init_seg_name", "init-$RepresentationID$.m4s",
+		"-media_seg_name", "chunk-$RepresentationID$-$Number%05d$.m4s",
+		"-adaptation_sets", adaptationSets,
+		filepath.Join(outDir, "manifest.mpd"),
+	)
+
+	return f.run(ctx, "ffmpeg dash", args...)
+}
+
+// run ffmpegni ishga tushiradi; xatoda chiqishi xabarga qo'shiladi
+func (f *FFmpeg) run(ctx context.Context, what string, args ...string) error {
+	cmd := exec.CommandContext(ctx, f.ffmpegPath, args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s xatosi: %w: %s", what, err, output)
+	}
+	return nil
+}
+
+// runWithProgress ffmpegni `-progress pipe:1` bilan ishga tushiradi va
+// har bir progress blokida onProgress ni chaqiradi
+func (f *FFmpeg) runWithProgress(ctx context.Context, args []string, onProgress func(Progress)) error {
+	args = append([]string{"-progress", "pipe:1", "-nostats"}, args...)
+	cmd := exec.CommandContext(ctx, f.ffmpegPath, args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if onProgress == nil {
+		onProgress = func(Progress) {}
+	}
+	progressErr := parseProgress(stdout, onProgress)
+	if progressErr != nil {
+		// Skaner to'xtadi (juda uzun qator yoki o'qish xatosi): pipe to'lib
+		// ffmpeg (va cmd.Wait) qotib qolmasligi uchun qolganini o'qib tashlash
+		io.Copy(io.Discard, stdout)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("ffmpeg xatosi: %w: %s", err, stderr.Bytes())
+	}
+	if progressErr != nil {
+		return fmt.Errorf("ffmpeg progress o'qilmadi: %w", progressErr)
+	}
+	return nil
+}
+
+// parseProgress ffmpeg `-progress` chiqishini (key=value qatorlar)
+// o'qiydi va har bir blok oxirida (progress=continue|end) fn ni chaqiradi
+func parseProgress(r io.Reader, fn func(Progress)) error {
+	var current Progress
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "out_time_us", "out_time_ms": // ikkalasi ham mikrosoniya
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				current.OutTime = time.Duration(us) * time.Microsecond
+			}
+		case "speed":
+			if speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "x"), 64); err == nil {
+				current.Speed = speed
+			}
+		case "progress":
+			current.End = value == "end"
+			fn(current)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// videoFilter scale (aspect ratio saqlangan holda hisoblangan o'lcham) va fps cheklovi
+func (spec RenditionSpec) videoFilter() string {
+	filter := fmt.Sprintf("scale=%d:%d,setsar=1", spec.Width, spec.Height)
+	if spec.FPSLimit > 0 {
+		filter += fmt.Sprintf(",fps=%g", spec.FPSLimit)
+	}
+	return filter
+}
+
+// audioArgs ffmpeg audio parametrlari
+func (spec RenditionSpec) audioArgs() []string {
+	if spec.AudioBitrate == 0 {
+		return []string{"-an"}
+	}
+	return []string{"-c:a", "aac", "-b:a", fmt.Sprintf("%dk", spec.AudioBitrate), "-ac", "2"}
+}
+
+// formatSeconds ffmpeg uchun vaqt (soniya, millisekund aniqlikda)
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
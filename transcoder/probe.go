@@ -0,0 +1,153 @@
+// transcoder/probe.go
+package transcoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+)
+
+// ffprobeOutput - `ffprobe -print_format json -show_format -show_streams` javobi
+type ffprobeOutput struct {
+	Streams []ffprobeStream `json:"streams"`
+	Format  struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		Size       string `json:"size"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+type ffprobeStream struct {
+	CodecType          string            `json:"codec_type"`
+	CodecName          string            `json:"codec_name"`
+	Profile            string            `json:"profile"`
+	PixFmt             string            `json:"pix_fmt"`
+	Width              int               `json:"width"`
+	Height             int               `json:"height"`
+	SampleAspectRatio  string            `json:"sample_aspect_ratio"`
+	DisplayAspectRatio string            `json:"display_aspect_ratio"`
+	RFrameRate         string            `json:"r_frame_rate"`
+	AvgFrameRate       string            `json:"avg_frame_rate"`
+	BitRate            string            `json:"bit_rate"`
+	SampleRate         string            `json:"sample_rate"`
+	Channels           int               `json:"channels"`
+	Tags               map[string]string `json:"tags"`
+	SideDataList       []struct {
+		Rotation float64 `json:"rotation"`
+	} `json:"side_data_list"`
+	Disposition struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+}
+
+// parseFFprobe ffprobe JSON javobini MediaInfo ga aylantiradi
+func parseFFprobe(data []byte) (*models.MediaInfo, error) {
+	var out ffprobeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("ffprobe javobi noto'g'ri: %w", err)
+	}
+
+	info := &models.MediaInfo{
+		Container: out.Format.FormatName,
+		Duration:  parseFloat(out.Format.Duration),
+		Size:      parseInt(out.Format.Size),
+		Bitrate:   parseInt(out.Format.BitRate),
+	}
+
+	for _, stream := range out.Streams {
+		switch stream.CodecType {
+		case "video":
+			// Albom muqovasi (cover art) video oqim emas
+			if info.Video != nil || stream.Disposition.AttachedPic == 1 {
+				continue
+			}
+
+			frameRate := parseRatio(stream.AvgFrameRate)
+			if frameRate <= 0 {
+				frameRate = parseRatio(stream.RFrameRate)
+			}
+
+			info.Video = &models.VideoStreamInfo{
+				Codec:         stream.CodecName,
+				Profile:       stream.Profile,
+				PixelFormat:   stream.PixFmt,
+				Width:         stream.Width,
+				Height:        stream.Height,
+				SampleAspect:  stream.SampleAspectRatio,
+				DisplayAspect: stream.DisplayAspectRatio,
+				Rotation:      streamRotation(stream),
+				FrameRate:     math.Round(frameRate*1000) / 1000,
+				Bitrate:       parseInt(stream.BitRate),
+			}
+		case "audio":
+			if info.Audio != nil {
+				continue
+			}
+
+			info.Audio = &models.AudioStreamInfo{
+				Codec:      stream.CodecName,
+				Channels:   stream.Channels,
+				SampleRate: int(parseInt(stream.SampleRate)),
+				Bitrate:    parseInt(stream.BitRate),
+			}
+		}
+	}
+
+	if info.Video == nil {
+		return info, fmt.Errorf("faylda video oqim topilmadi")
+	}
+
+	return info, nil
+}
+
+// streamRotation 0/90/180/270 ga normallashtirilgan soat yo'nalishidagi burilish.
+// Yangi ffmpeg display matrix (side data, soat teskarisiga) beradi,
+// eskilari esa "rotate" tegini (soat yo'nalishida).
+func streamRotation(stream ffprobeStream) int {
+	var degrees float64
+	if rotate, ok := stream.Tags["rotate"]; ok {
+		degrees = parseFloat(rotate)
+	} else {
+		for _, side := range stream.SideDataList {
+			if side.Rotation != 0 {
+				degrees = -side.Rotation
+				break
+			}
+		}
+	}
+
+	rotation := int(math.Round(degrees/90)) * 90 % 360
+	if rotation < 0 {
+		rotation += 360
+	}
+	return rotation
+}
+
+// parseRatio "30000/1001" ko'rinishidagi kasrni hisoblaydi
+func parseRatio(value string) float64 {
+	num, den, ok := strings.Cut(value, "/")
+	if !ok {
+		return parseFloat(value)
+	}
+
+	d := parseFloat(den)
+	if d == 0 {
+		return 0
+	}
+	return parseFloat(num) / d
+}
+
+func parseFloat(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+func parseInt(value string) int64 {
+	i, _ := strconv.ParseInt(value, 10, 64)
+	return i
+}
@@ -0,0 +1,68 @@
+// transcoder/transcoder.go
+package transcoder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/models"
+)
+
+// RenditionSpec - bitta sifat variantini kodlash parametrlari
+type RenditionSpec struct {
+	Width            int
+	Height           int
+	FPSLimit         float64 // 0 - manba fps saqlanadi
+	VideoBitrate     int     // kbps (maxrate)
+	AudioBitrate     int     // kbps, 0 - audio yo'q
+	Level            string  // H.264 level
+	KeyframeInterval int     // soniya; HLS/DASH segmentlari bir xil joyda kesilishi uchun
+}
+
+// AudioSpec - audio-only chiqish parametrlari
+type AudioSpec struct {
+	Codec    string // "aac" yoki "opus"
+	Bitrate  int    // kbps
+	Channels int
+}
+
+// Progress - kodlash jarayonidagi joriy holat
+type Progress struct {
+	OutTime time.Duration // qayta ishlangan media vaqti
+	Speed   float64       // 1.0 = real vaqt
+	End     bool
+}
+
+// Transcoder - media bilan ishlovchi tashqi vosita (ffmpeg) abstraksiyasi.
+// Barcha yo'llar lokal fayl tizimidagi fayllar.
+type Transcoder interface {
+	// Probe fayl haqida ma'lumot (o'lcham, fps, codec, bitrate, davomiylik)
+	Probe(ctx context.Context, input string) (*models.MediaInfo, error)
+	// TranscodeRendition bitta H.264/AAC MP4 sifat variantini yaratadi
+	TranscodeRendition(ctx context.Context, input, output string, spec RenditionSpec, onProgress func(Progress)) error
+	// ExtractFrame at vaqtdagi kadrni JPEG ko'rinishida saqlaydi (height <= 0 - aspect ratio saqlanadi)
+	ExtractFrame(ctx context.Context, input, output string, at time.Duration, width, height int) error
+	// ExtractFrames har interval da bitta kadrni width kenglikda (aspect ratio saqlangan)
+	// outDir ga JPEG qilib yozadi. i-kadr i*interval vaqtga mos; yo'llar tartib bilan qaytadi.
+	ExtractFrames(ctx context.Context, input, outDir string, interval time.Duration, width int) ([]string, error)
+	// ExtractAudio audio yo'lakni alohida faylga chiqaradi
+	ExtractAudio(ctx context.Context, input, output string, spec AudioSpec) error
+	// PackageHLS tayyor MP4 ni qayta kodlamasdan HLS segmentlariga bo'ladi (outDir/index.m3u8)
+	PackageHLS(ctx context.Context, input, outDir string, segmentTime int) error
+	// PackageDASH tayyor MP4 larni bitta MPD ostida fMP4 segmentlarga bo'ladi (outDir/manifest.mpd).
+	// hasAudio bo'lsa audio oxirgi inputdan alohida AdaptationSet sifatida olinadi.
+	PackageDASH(ctx context.Context, inputs []string, hasAudio bool, outDir string, segmentTime int) error
+}
+
+// New nomi bo'yicha transcoder tanlaydi ("ffmpeg" yoki testlar uchun "fake")
+func New(name string) (Transcoder, error) {
+	switch name {
+	case "", "ffmpeg":
+		return NewFFmpeg("ffmpeg", "ffprobe"), nil
+	case "fake":
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("noma'lum transcoder: %s", name)
+	}
+}
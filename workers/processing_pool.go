@@ -0,0 +1,301 @@
+// workers/processing_pool.go
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/queue"
+	"github.com/Coding-for-Machine/Videos-Service/services"
+)
+
+// jobHandler bitta job turini bajaradi
+type jobHandler func(ctx context.Context, job models.ProcessingJob) error
+
+// ProcessingPool - job turi bo'yicha cheklangan sonli goroutinelar.
+//
+// Har bir tur uchun Concurrency ta worker ishlaydi va har biri navbatdan
+// faqat bo'sh bo'lganda yangi job oladi. Shu sababli pool band bo'lsa
+// Redisdan job tortish to'xtaydi (backpressure) va joblar boshqa replikalar
+// uchun navbatda qoladi.
+type ProcessingPool struct {
+	queues     queue.Queues
+	cfg        config.QueueConfig
+	handlers   map[string]jobHandler
+	videos     *services.VideoService
+	jobService *services.JobService
+	stats      map[string]*poolStats
+}
+
+type poolStats struct {
+	inFlight  atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+}
+
+func NewProcessingPool(queues queue.Queues, cfg config.QueueConfig, processingService *services.ProcessingService, videoService *services.VideoService, jobService *services.JobService) *ProcessingPool {
+	p := &ProcessingPool{
+		queues:     queues,
+		cfg:        cfg,
+		videos:     videoService,
+		jobService: jobService,
+		stats:      make(map[string]*poolStats),
+		handlers: map[string]jobHandler{
+			"transcode": func(ctx context.Context, job models.ProcessingJob) error {
+				return processTranscodeJob(ctx, job, processingService, videoService)
+			},
+			"thumbnail": func(ctx context.Context, job models.ProcessingJob) error {
+				return processThumbnailJob(ctx, job, processingService, videoService)
+			},
+			"probe": func(ctx context.Context, job models.ProcessingJob) error {
+				return processProbeJob(ctx, job, processingService, videoService)
+			},
+			"extract_audio": func(ctx context.Context, job models.ProcessingJob) error {
+				return processExtractAudioJob(ctx, job, processingService, videoService)
+			},
+			"sprites": func(ctx context.Context, job models.ProcessingJob) error {
+				return processSpritesJob(ctx, job, processingService, videoService)
+			},
+		},
+	}
+
+	for _, jobType := range queues.Types() {
+		p.stats[jobType] = &poolStats{}
+	}
+
+	return p
+}
+
+// Run har bir job turi uchun workerlarni ishga tushiradi va ctx tugaguncha kutadi
+func (p *ProcessingPool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, jobType := range p.queues.Types() {
+		concurrency := p.cfg.Concurrency[jobType]
+		if concurrency <= 0 {
+			continue
+		}
+
+		log.Printf("Processing pool: %s x%d (worker: %s)", jobType, concurrency, p.cfg.WorkerID)
+
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.work(ctx, jobType)
+			}()
+		}
+	}
+
+	wg.Wait()
+}
+
+// work navbatdan bittadan job olib, uni tugatmaguncha keyingisini olmaydi
+func (p *ProcessingPool) work(ctx context.Context, jobType string) {
+	jobs := p.queues[jobType]
+	workerID := p.cfg.WorkerID
+
+	for {
+		payload, err := jobs.Dequeue(ctx, workerID, 5*time.Second)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Queue xatosi (%s): %v", jobType, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		if payload == "" {
+			continue
+		}
+
+		var job models.ProcessingJob
+		if err := json.Unmarshal([]byte(payload), &job); err != nil {
+			log.Printf("Job parse xatosi: %v", err)
+			// Buzilgan xabarni qayta-qayta olmaslik uchun ack qilamiz
+			jobs.Ack(ctx, workerID, payload)
+			continue
+		}
+
+		p.runJob(ctx, jobs, payload, job)
+	}
+}
+
+func (p *ProcessingPool) runJob(ctx context.Context, jobs *queue.Queue, payload string, job models.ProcessingJob) {
+	workerID := p.cfg.WorkerID
+	stats := p.stats[job.JobType]
+	if stats == nil {
+		stats = &poolStats{}
+	}
+
+	stats.inFlight.Add(1)
+	defer stats.inFlight.Add(-1)
+
+	// Uzoq davom etadigan transcoding vaqtida lease'ni uzaytirib turish
+	stop := jobs.Heartbeat(ctx, workerID, payload)
+	defer stop()
+
+	handler, ok := p.handlers[job.JobType]
+	if !ok {
+		// Qayta urinishdan foyda yo'q
+		log.Printf("Noma'lum job turi: %s", job.JobType)
+		job.ErrorMessage = fmt.Sprintf("noma'lum job turi: %s", job.JobType)
+		p.jobService.Transition(ctx, &job, "failed")
+		jobs.Ack(ctx, workerID, payload)
+		stats.failed.Add(1)
+		return
+	}
+
+	// Jobni processing statusga o'zgartirish
+	p.jobService.Transition(ctx, &job, "processing")
+	log.Printf("Job boshlandi: %s (type: %s, video: %s)", job.JobID, job.JobType, job.VideoID)
+
+	if err := handler(ctx, job); err != nil {
+		log.Printf("Job xatosi: %s (type: %s, urinish: %d): %v", job.JobID, job.JobType, job.RetryCount+1, err)
+		stats.failed.Add(1)
+		p.retryOrDeadLetter(ctx, jobs, payload, job, err)
+		return
+	}
+
+	stats.completed.Add(1)
+	p.jobService.Transition(ctx, &job, "completed")
+	if err := jobs.Ack(ctx, workerID, payload); err != nil {
+		log.Printf("Job ack xatosi: %s: %v", job.JobID, err)
+	}
+}
+
+// retryOrDeadLetter xato bilan tugagan jobni backoff bilan qayta navbatga
+// qo'yadi yoki urinishlar tugagan bo'lsa dead-letter ro'yxatiga o'tkazadi
+func (p *ProcessingPool) retryOrDeadLetter(ctx context.Context, jobs *queue.Queue, payload string, job models.ProcessingJob, jobErr error) {
+	policy := p.cfg.RetryPolicyFor(job.JobType)
+
+	job.RetryCount++
+	job.ErrorMessage = jobErr.Error()
+
+	if job.RetryCount < policy.MaxAttempts {
+		p.jobService.Transition(ctx, &job, "pending")
+		jobData, _ := json.Marshal(job)
+
+		delay := queue.Backoff(policy.BaseDelay, policy.MaxDelay, job.RetryCount)
+		if err := jobs.Retry(ctx, p.cfg.WorkerID, payload, jobData, delay); err != nil {
+			log.Printf("Job retry xatosi: %s: %v", job.JobID, err)
+			return
+		}
+		log.Printf("Job qayta navbatda: %s (%s dan keyin)", job.JobID, delay.Round(time.Second))
+		return
+	}
+
+	p.jobService.Transition(ctx, &job, "failed")
+	jobData, _ := json.Marshal(job)
+	if err := jobs.DeadLetter(ctx, p.cfg.WorkerID, payload, jobData); err != nil {
+		log.Printf("Dead-letter xatosi: %s: %v", job.JobID, err)
+		return
+	}
+	log.Printf("Job dead-letter navbatiga o'tkazildi: %s (%d urinish)", job.JobID, job.RetryCount)
+
+	// Transcoding bo'lmasa video hech qachon "ready" bo'lmaydi - foydalanuvchi
+	// "processing" holatida qolib ketmasligi uchun videoni failed qilamiz.
+	// Thumbnail xatosi videoni ishlatib bo'lmaydigan qilmaydi.
+	if job.JobType == "transcode" {
+		if err := p.videos.MarkFailed(ctx, job.VideoID, job.ErrorMessage); err != nil {
+			log.Printf("Video failed statusi yozilmadi: %s: %v", job.VideoID, err)
+		}
+	}
+}
+
+// reclaim lease muddati o'tgan joblarni qaytaradi. Worker jobni tugatmasdan
+// to'xtagan (yoki osilib qolgan) bo'lsa bu ham urinish hisoblanadi: RetryCount
+// oshiriladi va urinishlar tugaganda job dead-letterga o'tadi.
+func (p *ProcessingPool) reclaim(ctx context.Context, jobType string) (int, error) {
+	policy := p.cfg.RetryPolicyFor(jobType)
+
+	reclaimed, err := p.queues[jobType].Reclaim(ctx, func(payload string) ([]byte, bool) {
+		var job models.ProcessingJob
+		if err := json.Unmarshal([]byte(payload), &job); err != nil {
+			// Buzilgan xabarni qayta-qayta qaytarmaslik uchun
+			return []byte(payload), true
+		}
+
+		job.RetryCount++
+		job.ErrorMessage = "lease muddati o'tdi: worker jobni tugatmadi"
+		jobData, _ := json.Marshal(job)
+		return jobData, job.RetryCount >= policy.MaxAttempts
+	})
+
+	for _, r := range reclaimed {
+		var job models.ProcessingJob
+		if json.Unmarshal(r.Payload, &job) != nil {
+			continue
+		}
+
+		if !r.Dead {
+			p.jobService.Transition(ctx, &job, "pending")
+			continue
+		}
+
+		p.jobService.Transition(ctx, &job, "failed")
+		log.Printf("Job dead-letter navbatiga o'tkazildi: %s (%d urinish, lease muddati o'tdi)", job.JobID, job.RetryCount)
+		if job.JobType == "transcode" {
+			if err := p.videos.MarkFailed(ctx, job.VideoID, job.ErrorMessage); err != nil {
+				log.Printf("Video failed statusi yozilmadi: %s: %v", job.VideoID, err)
+			}
+		}
+	}
+
+	return len(reclaimed), err
+}
+
+// Metrics job turi bo'yicha navbat uzunligi va ishlanayotgan joblar
+func (p *ProcessingPool) Metrics(ctx context.Context) ([]services.PoolMetrics, error) {
+	var metrics []services.PoolMetrics
+
+	for _, jobType := range p.queues.Types() {
+		queueStats, err := p.queues[jobType].Stats(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		stats := p.stats[jobType]
+		metrics = append(metrics, services.PoolMetrics{
+			JobType:     jobType,
+			Concurrency: p.cfg.Concurrency[jobType],
+			InFlight:    stats.inFlight.Load(),
+			Completed:   stats.completed.Load(),
+			Failed:      stats.failed.Load(),
+			Queue:       queueStats,
+		})
+	}
+
+	return metrics, nil
+}
+
+// MigrateLegacyQueue job turlari bo'yicha navbatlarga o'tishdan oldingi umumiy
+// "processing_queue" dagi joblarni o'z turi navbatiga ko'chiradi (ishga
+// tushganda bir marta). Noma'lum turdagi joblar eski navbatda qoladi.
+func MigrateLegacyQueue(ctx context.Context, legacy *queue.Queue, queues queue.Queues) (int, error) {
+	return legacy.DrainInto(ctx, func(payload string) (*queue.Queue, bool) {
+		var job models.ProcessingJob
+		if err := json.Unmarshal([]byte(payload), &job); err != nil {
+			log.Printf("Eski navbatdagi job parse xatosi: %v", err)
+			return nil, false
+		}
+
+		target, err := queues.For(job.JobType)
+		if err != nil {
+			log.Printf("Eski navbatdagi job ko'chirilmadi: %s: %v", job.JobID, err)
+			return nil, false
+		}
+		return target, true
+	})
+}
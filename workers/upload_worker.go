@@ -0,0 +1,43 @@
+// workers/upload_worker.go
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/services"
+)
+
+// Upload expiration worker - muddati o'tgan resumable uploadlar va presigned sessiyalarni tozalaydi
+func UploadExpirationWorker(ctx context.Context, uploadService *services.UploadService) {
+	log.Println("Upload Expiration Worker ishga tushdi")
+
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			expired, err := uploadService.ExpireUploads(ctx)
+			if err != nil {
+				log.Printf("Upload tozalash xatosi: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Muddati o'tgan uploadlar o'chirildi: %d", expired)
+			}
+
+			sessions, err := uploadService.ExpireUploadSessions(ctx)
+			if err != nil {
+				log.Printf("Upload sessiyalarini tozalash xatosi: %v", err)
+				continue
+			}
+			if sessions > 0 {
+				log.Printf("Muddati o'tgan upload sessiyalari o'chirildi: %d", sessions)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
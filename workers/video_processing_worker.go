@@ -3,7 +3,7 @@ package workers
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
@@ -12,113 +12,145 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-// Video processing worker - videolarni transcoding qiladi
-func VideoProcessingWorker(ctx context.Context, redis *redis.Client, processingService *services.ProcessingService, videoService *services.VideoService) {
-	log.Println("Video Processing Worker ishga tushdi")
+func processTranscodeJob(ctx context.Context, job models.ProcessingJob, processingService *services.ProcessingService, videoService *services.VideoService) error {
+	// Videoni olish
+	video, err := videoService.GetVideo(ctx, job.VideoID.String())
+	if err != nil {
+		return err
+	}
 
-	for {
-		// Redis queuedan jobni olish
-		result, err := redis.BLPop(ctx, 0, "processing_queue").Result()
-		if err != nil {
-			log.Printf("Queue xatosi: %v", err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
+	// Transcoding
+	result, err := processingService.TranscodeVideo(ctx, job.VideoID, video.FileName)
+	if err != nil {
+		return fmt.Errorf("transcoding xatosi: %w", err)
+	}
 
-		var job models.ProcessingJob
-		if err := json.Unmarshal([]byte(result[1]), &job); err != nil {
-			log.Printf("Job parse xatosi: %v", err)
-			continue
-		}
+	// Statusni va sifat URLlarini yangilash (thumbnail alohida job)
+	err = videoService.MarkReady(ctx, job.VideoID, result.DefaultURL(), result.QualityVersions)
+	if err != nil {
+		return fmt.Errorf("status yangilash xatosi: %w", err)
+	}
+
+	log.Printf("Transcoding tugadi: %s", job.VideoID)
+	return nil
+}
 
-		// Jobni processing statusga o'zgartirish
-		job.Status = "processing"
-		job.UpdatedAt = time.Now()
+// processThumbnailJob thumbnail variantlarini yaratadi; eng yaxshisi asosiy
+// thumbnail bo'ladi (agar ega o'zinikini allaqachon qo'ymagan bo'lsa)
+func processThumbnailJob(ctx context.Context, job models.ProcessingJob, processingService *services.ProcessingService, videoService *services.VideoService) error {
+	video, err := videoService.GetVideo(ctx, job.VideoID.String())
+	if err != nil {
+		return err
+	}
 
-		log.Printf("Job boshlandi: %s (type: %s, video: %s)", job.JobID, job.JobType, job.VideoID)
+	candidates, err := processingService.GenerateThumbnails(ctx, job.VideoID, video.FileName)
+	if err != nil {
+		return fmt.Errorf("thumbnail xatosi: %w", err)
+	}
 
-		// Job turini tekshirish
-		switch job.JobType {
-		case "transcode":
-			go processTranscodeJob(ctx, job, processingService, videoService)
-		case "thumbnail":
-			go processThumbnailJob(ctx, job, processingService, videoService)
-		default:
-			log.Printf("Noma'lum job turi: %s", job.JobType)
-		}
+	if err := videoService.SetGeneratedThumbnails(ctx, job.VideoID, candidates); err != nil {
+		return fmt.Errorf("thumbnail saqlash xatosi: %w", err)
 	}
+
+	log.Printf("Thumbnail yaratildi: %s", job.VideoID)
+	return nil
 }
 
-func processTranscodeJob(ctx context.Context, job models.ProcessingJob, processingService *services.ProcessingService, videoService *services.VideoService) {
-	// Videoni olish
+// processProbeJob manba fayl metadatasini (o'lcham, fps, codec, bitrate,
+// davomiylik) aniqlab videoga yozadi
+func processProbeJob(ctx context.Context, job models.ProcessingJob, processingService *services.ProcessingService, videoService *services.VideoService) error {
 	video, err := videoService.GetVideo(ctx, job.VideoID.String())
 	if err != nil {
-		log.Printf("Video topilmadi: %v", err)
-		return
+		return err
 	}
 
-	// Transcoding
-	qualityVersions, err := processingService.TranscodeVideo(ctx, job.VideoID, video.FileName)
+	info, err := processingService.ProbeVideo(ctx, job.VideoID, video.FileName)
 	if err != nil {
-		log.Printf("Transcoding xatosi: %v", err)
-		job.Status = "failed"
-		job.ErrorMessage = err.Error()
-		return
+		return fmt.Errorf("probe xatosi: %w", err)
+	}
+
+	if err := videoService.SetMediaInfo(ctx, job.VideoID, info); err != nil {
+		return fmt.Errorf("media info saqlash xatosi: %w", err)
 	}
 
-	// Video URLni yangilash
-	videoURL := qualityVersions["720p"] // Default quality
-	if videoURL == "" {
-		videoURL = qualityVersions["480p"]
+	log.Printf("Probe tugadi: %s (%s, %.0fs)", job.VideoID, info.Container, info.Duration)
+	return nil
+}
+
+// processExtractAudioJob "listen mode" uchun audio-only renditionlarni
+// yaratadi va videoga yozadi
+func processExtractAudioJob(ctx context.Context, job models.ProcessingJob, processingService *services.ProcessingService, videoService *services.VideoService) error {
+	video, err := videoService.GetVideo(ctx, job.VideoID.String())
+	if err != nil {
+		return err
 	}
 
-	// Statusni yangilash
-	err = videoService.UpdateVideoStatus(ctx, job.VideoID, "ready", videoURL, video.ThumbnailURL)
+	versions, err := processingService.ExtractAudio(ctx, job.VideoID, video.FileName)
 	if err != nil {
-		log.Printf("Status yangilash xatosi: %v", err)
-		return
+		return fmt.Errorf("audio ajratish xatosi: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil // manbada audio yo'q
 	}
 
-	job.Status = "completed"
-	job.UpdatedAt = time.Now()
-	log.Printf("Transcoding tugadi: %s", job.VideoID)
+	if err := videoService.SetAudioVersions(ctx, job.VideoID, versions); err != nil {
+		return fmt.Errorf("audio versiyalarni saqlash xatosi: %w", err)
+	}
+
+	log.Printf("Audio tayyor: %s", job.VideoID)
+	return nil
 }
 
-func processThumbnailJob(ctx context.Context, job models.ProcessingJob, processingService *services.ProcessingService, videoService *services.VideoService) {
+// processSpritesJob seek bar preview uchun sprite sheet va WebVTT track yaratadi
+func processSpritesJob(ctx context.Context, job models.ProcessingJob, processingService *services.ProcessingService, videoService *services.VideoService) error {
 	video, err := videoService.GetVideo(ctx, job.VideoID.String())
 	if err != nil {
-		log.Printf("Video topilmadi: %v", err)
-		return
+		return err
 	}
 
-	// Thumbnail yaratish
-	thumbnailURL, err := processingService.GenerateThumbnail(ctx, job.VideoID, video.FileName)
+	trackURL, err := processingService.GenerateSprites(ctx, job.VideoID, video.FileName)
 	if err != nil {
-		log.Printf("Thumbnail xatosi: %v", err)
-		job.Status = "failed"
-		job.ErrorMessage = err.Error()
-		return
+		return fmt.Errorf("sprite xatosi: %w", err)
 	}
 
-	// Video davomiyligini olish
-	duration, _ := processingService.GetVideoDuration(ctx, job.VideoID, video.FileName)
-
-	// Ma'lumotlarni yangilash
-	err = videoService.UpdateVideoStatus(ctx, job.VideoID, video.Status, video.VideoURL, thumbnailURL)
-	if err != nil {
-		log.Printf("Status yangilash xatosi: %v", err)
-		return
+	if err := videoService.SetPreviewTrack(ctx, job.VideoID, trackURL); err != nil {
+		return fmt.Errorf("preview track saqlash xatosi: %w", err)
 	}
 
-	job.Status = "completed"
-	job.UpdatedAt = time.Now()
-	log.Printf("Thumbnail yaratildi: %s (duration: %d)", job.VideoID, duration)
+	log.Printf("Preview track tayyor: %s", job.VideoID)
+	return nil
 }
 
-// Thumbnail generator worker
-func ThumbnailGeneratorWorker(ctx context.Context, redis *redis.Client, processingService *services.ProcessingService, videoService *services.VideoService) {
-	log.Println("Thumbnail Generator Worker ishga tushdi")
-	// VideoProcessingWorker bilan bir xil mantiq
+// Queue reclaim worker - lease muddati o'tgan (ack qilinmagan) va
+// kechiktirish vaqti kelgan (retry) joblarni navbatga qaytaradi. Lease muddati
+// o'tishi urinish hisoblanadi (ProcessingPool.reclaim).
+// Bir nechta replikada parallel ishlashi xavfsiz.
+func QueueReclaimWorker(ctx context.Context, pool *ProcessingPool) {
+	log.Println("Queue Reclaim Worker ishga tushdi")
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, jobType := range pool.queues.Types() {
+				if _, err := pool.queues[jobType].PromoteDelayed(ctx); err != nil {
+					log.Printf("Delayed joblar xatosi (%s): %v", jobType, err)
+				}
+
+				n, err := pool.reclaim(ctx, jobType)
+				if err != nil {
+					log.Printf("Reclaim xatosi (%s): %v", jobType, err)
+				}
+				if n > 0 {
+					log.Printf("Navbatga qaytarilgan joblar (%s): %d", jobType, n)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
 }
 
 // View counter worker - viewlarni batch rejimida yangilaydi
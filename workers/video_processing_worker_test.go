@@ -0,0 +1,255 @@
+// workers/video_processing_worker_test.go
+package workers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Coding-for-Machine/Videos-Service/assets"
+	"github.com/Coding-for-Machine/Videos-Service/config"
+	"github.com/Coding-for-Machine/Videos-Service/models"
+	"github.com/Coding-for-Machine/Videos-Service/queue"
+	"github.com/Coding-for-Machine/Videos-Service/repository"
+	"github.com/Coding-for-Machine/Videos-Service/services"
+	"github.com/Coding-for-Machine/Videos-Service/storage"
+	"github.com/Coding-for-Machine/Videos-Service/transcoder"
+	"github.com/Coding-for-Machine/Videos-Service/workspace"
+	"github.com/gocql/gocql"
+	"github.com/redis/go-redis/v9"
+)
+
+var testBuckets = config.Buckets{
+	Videos:     "videos",
+	Raw:        "videos-raw",
+	Processed:  "videos-processed",
+	Thumbnails: "thumbnails",
+}
+
+// flowEnv xotiradagi repositorylar, lokal storage va Fake transcoder ustidagi pipeline
+type flowEnv struct {
+	store      *storage.LocalStore
+	fake       *transcoder.Fake
+	videos     *services.VideoService
+	processing *services.ProcessingService
+}
+
+func newFlowEnv(t *testing.T) *flowEnv {
+	t.Helper()
+
+	store, err := storage.NewLocalStore(t.TempDir(), testBuckets.All(), "http://localhost", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	workspaces, err := workspace.NewManager(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ulanib bo'lmaydigan Redis: navbat va view queue bu testda ishlatilmaydi
+	rdb := redis.NewClient(&redis.Options{
+		MaxRetries: -1,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("testda redis yo'q")
+		},
+	})
+	t.Cleanup(func() { rdb.Close() })
+
+	fake := transcoder.NewFake()
+	cfg := config.ProcessingConfig{
+		HLSEnabled:     true,
+		HLSSegmentTime: 4,
+		DASHEnabled:    true,
+	}
+
+	return &flowEnv{
+		store:      store,
+		fake:       fake,
+		videos:     services.NewVideoService(repository.NewMemoryRepositories(), store, testBuckets, rdb, queue.Queues{}),
+		processing: services.NewProcessingService(fake, store, testBuckets, nil, workspaces, cfg),
+	}
+}
+
+// uploaded "uploading" holatida video yaratadi, raw faylni yozadi va
+// upload tugaganini belgilaydi (presigned upload oqimi)
+func (e *flowEnv) uploaded(t *testing.T) *models.Video {
+	t.Helper()
+	ctx := context.Background()
+
+	videoID := gocql.TimeUUID()
+	video, err := e.videos.CreatePendingVideo(ctx, videoID, "Flow", "tavsif", gocql.TimeUUID(), "tester", 10, "clip.mp4")
+	if err != nil {
+		t.Fatalf("CreatePendingVideo: %v", err)
+	}
+	if video.Status != "uploading" {
+		t.Fatalf("status = %q, kutilgan uploading", video.Status)
+	}
+
+	raw := strings.NewReader("fake video")
+	if err := e.store.Put(ctx, testBuckets.Raw, services.RawObjectName(videoID, "clip.mp4"), raw, raw.Size(), "video/mp4"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.videos.MarkUploaded(ctx, videoID); err != nil {
+		t.Fatalf("MarkUploaded: %v", err)
+	}
+	e.expectStatus(t, videoID, "processing")
+
+	return video
+}
+
+func (e *flowEnv) expectStatus(t *testing.T, videoID gocql.UUID, want string) *models.Video {
+	t.Helper()
+
+	video, err := e.videos.GetVideo(context.Background(), videoID.String())
+	if err != nil {
+		t.Fatalf("GetVideo: %v", err)
+	}
+	if video.Status != want {
+		t.Fatalf("status = %q, kutilgan %q", video.Status, want)
+	}
+	return video
+}
+
+func (e *flowEnv) expectObject(t *testing.T, asset assets.Asset) {
+	t.Helper()
+
+	if _, err := e.store.Stat(context.Background(), asset.Bucket(testBuckets), asset.Key()); err != nil {
+		t.Errorf("%s topilmadi: %v", asset.Key(), err)
+	}
+}
+
+func (e *flowEnv) readObject(t *testing.T, asset assets.Asset) string {
+	t.Helper()
+
+	object, err := e.store.Get(context.Background(), asset.Bucket(testBuckets), asset.Key())
+	if err != nil {
+		t.Fatalf("%s topilmadi: %v", asset.Key(), err)
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func newJob(videoID gocql.UUID, jobType string) models.ProcessingJob {
+	return models.ProcessingJob{
+		JobID:     gocql.TimeUUID(),
+		VideoID:   videoID,
+		JobType:   jobType,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestTranscodeFlow(t *testing.T) {
+	env := newFlowEnv(t)
+	ctx := context.Background()
+
+	video := env.uploaded(t)
+
+	if err := processTranscodeJob(ctx, newJob(video.ID, "transcode"), env.processing, env.videos); err != nil {
+		t.Fatalf("processTranscodeJob: %v", err)
+	}
+
+	ready := env.expectStatus(t, video.ID, "ready")
+
+	// Fake manba 1080p: ladder 1080p gacha, standart sifat 720p
+	for _, quality := range []string{"360p", "720p", "1080p"} {
+		url, ok := ready.QualityVersions[quality]
+		if !ok {
+			t.Errorf("QualityVersions da %s yo'q: %v", quality, ready.QualityVersions)
+			continue
+		}
+		if url != assets.Rendition(video.ID, quality).URL() {
+			t.Errorf("QualityVersions[%s] = %q", quality, url)
+		}
+		env.expectObject(t, assets.Rendition(video.ID, quality))
+		env.expectObject(t, assets.Of(video.ID, assets.HLS, quality+"/index.m3u8"))
+		env.expectObject(t, assets.Of(video.ID, assets.HLS, quality+"/segment_00000.ts"))
+	}
+	if ready.VideoURL != assets.Rendition(video.ID, "720p").URL() {
+		t.Errorf("VideoURL = %q", ready.VideoURL)
+	}
+
+	env.expectObject(t, assets.Of(video.ID, assets.HLS, "master.m3u8"))
+	env.expectObject(t, assets.Of(video.ID, assets.DASH, "manifest.mpd"))
+	env.expectObject(t, assets.Of(video.ID, assets.DASH, "init-0.m4s"))
+}
+
+func TestTranscodeFlowFailure(t *testing.T) {
+	env := newFlowEnv(t)
+	ctx := context.Background()
+
+	env.fake.Errors["TranscodeRendition"] = errors.New("ffmpeg yiqildi")
+	video := env.uploaded(t)
+
+	jobErr := processTranscodeJob(ctx, newJob(video.ID, "transcode"), env.processing, env.videos)
+	if jobErr == nil {
+		t.Fatal("transcoding xatosi kutilgan")
+	}
+
+	// Job xatosi videoni o'zgartirmaydi; failed holatini urinishlar tugaganda pool yozadi
+	failed := env.expectStatus(t, video.ID, "processing")
+	if len(failed.QualityVersions) != 0 || failed.VideoURL != "" {
+		t.Errorf("xatodan keyin sifatlar yozilgan: %v %q", failed.QualityVersions, failed.VideoURL)
+	}
+
+	master := assets.Of(video.ID, assets.HLS, "master.m3u8")
+	if _, err := env.store.Stat(ctx, master.Bucket(testBuckets), master.Key()); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("xatodan keyin master playlist: %v", err)
+	}
+	for _, call := range env.fake.Calls() {
+		if call == "PackageHLS" || call == "PackageDASH" {
+			t.Errorf("xatodan keyin %s chaqirilgan", call)
+		}
+	}
+
+	if err := env.videos.MarkFailed(ctx, video.ID, jobErr.Error()); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	env.expectStatus(t, video.ID, "failed")
+}
+
+// Master playlist transcode va extract_audio joblari qaysi tartibda tugashidan
+// qat'i nazar video va audio variantlarini o'z ichiga oladi
+func TestMasterPlaylistJobOrder(t *testing.T) {
+	ctx := context.Background()
+
+	steps := map[string]func(env *flowEnv, videoID gocql.UUID) error{
+		"transcode": func(env *flowEnv, videoID gocql.UUID) error {
+			return processTranscodeJob(ctx, newJob(videoID, "transcode"), env.processing, env.videos)
+		},
+		"extract_audio": func(env *flowEnv, videoID gocql.UUID) error {
+			return processExtractAudioJob(ctx, newJob(videoID, "extract_audio"), env.processing, env.videos)
+		},
+	}
+
+	for _, order := range [][]string{{"transcode", "extract_audio"}, {"extract_audio", "transcode"}} {
+		t.Run(strings.Join(order, "-"), func(t *testing.T) {
+			env := newFlowEnv(t)
+			video := env.uploaded(t)
+
+			for _, step := range order {
+				if err := steps[step](env, video.ID); err != nil {
+					t.Fatalf("%s: %v", step, err)
+				}
+			}
+
+			master := env.readObject(t, assets.Of(video.ID, assets.HLS, "master.m3u8"))
+			for _, variant := range []string{"hls/720p/index.m3u8", "hls/audio/index.m3u8"} {
+				if strings.Count(master, variant) != 1 {
+					t.Errorf("master playlistda %s bitta emas:\n%s", variant, master)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+// workspace/diskfree_other.go
+//go:build !unix
+
+package workspace
+
+import "errors"
+
+// freeSpace bu platformada qo'llab-quvvatlanmaydi - tekshiruv o'tkazib yuboriladi
+func freeSpace(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,15 @@
+// workspace/diskfree_unix.go
+//go:build unix
+
+package workspace
+
+import "syscall"
+
+// freeSpace papka joylashgan fayl tizimidagi bo'sh joy (bayt, root bo'lmagan foydalanuvchi uchun)
+func freeSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,9 @@
+// workspace/process_other.go
+//go:build !unix
+
+package workspace
+
+// processAlive bu platformada tekshirib bo'lmaydi - papka ishlatilayotgan deb hisoblanadi
+func processAlive(pid int) bool {
+	return true
+}
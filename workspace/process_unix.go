@@ -0,0 +1,15 @@
+// workspace/process_unix.go
+//go:build unix
+
+package workspace
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive shu hostda pid li jarayon ishlayaptimi (signal 0 yuboriladi)
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
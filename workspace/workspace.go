@@ -0,0 +1,258 @@
+// workspace/workspace.go
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInsufficientSpace - diskda ish boshlash uchun joy yetarli emas
+var ErrInsufficientSpace = errors.New("diskda bo'sh joy yetarli emas")
+
+// Manager - videolar uchun vaqtinchalik ish papkalari.
+//
+// Bitta video bo'yicha bir vaqtda ishlayotgan joblar (probe, thumbnail,
+// transcode) bitta papkani va bitta yuklab olingan manba faylni bo'lishadi.
+// Oxirgi foydalanuvchi Release qilgandan keyin papka retention davomida
+// saqlanadi: video joblari navbatdan turli vaqtda olinadi va keyingi job manba
+// faylni qayta yuklab olmasligi kerak.
+//
+// Har bir jarayon umumiy WORKSPACE_DIR ichida o'z "<host>-<pid>" papkasini
+// ishlatadi, shuning uchun bir papkani bo'lishgan replikalar bir-birining
+// fayllarini o'chirmaydi.
+type Manager struct {
+	root      string
+	minFree   uint64 // ishdan keyin ham diskda qolishi kerak bo'lgan joy (bayt)
+	retention time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	dir  string
+	refs int
+
+	expiry *time.Timer // refs 0 bo'lganda papkani o'chiradigan taymer
+
+	sourceMu sync.Mutex
+	source   string // yuklab olingan manba fayl ("" - hali yo'q)
+}
+
+func NewManager(root string, minFree uint64, retention time.Duration) (*Manager, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+
+	cleanupStale(root, host)
+
+	dir := filepath.Join(root, fmt.Sprintf("%s-%d", host, os.Getpid()))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("workspace papkasi yaratilmadi: %w", err)
+	}
+
+	return &Manager{
+		root:      dir,
+		minFree:   minFree,
+		retention: retention,
+		entries:   make(map[string]*entry),
+	}, nil
+}
+
+// cleanupStale shu hostda to'xtagan (crash) jarayonlardan qolib ketgan
+// "<host>-<pid>" papkalarini o'chiradi. Boshqa hostlar va ishlayotgan
+// jarayonlarning papkalariga tegilmaydi.
+func cleanupStale(root, host string) {
+	dirs, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		pid, ok := strings.CutPrefix(dir.Name(), host+"-")
+		if !dir.IsDir() || !ok {
+			continue
+		}
+		n, err := strconv.Atoi(pid)
+		if err != nil {
+			continue
+		}
+		// Shu PID li papka oldingi jarayonniki (masalan konteynerda PID 1)
+		if n == os.Getpid() || !processAlive(n) {
+			os.RemoveAll(filepath.Join(root, dir.Name()))
+		}
+	}
+}
+
+// Acquire video uchun workspace oladi (kerak bo'lsa yaratadi).
+// Har bir Acquire dan keyin Release chaqirilishi shart.
+func (m *Manager) Acquire(key string) (*Workspace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if ok && e.expiry != nil {
+		e.expiry.Stop()
+		e.expiry = nil
+	}
+	if !ok {
+		dir, err := os.MkdirTemp(m.root, key+"-")
+		if err != nil {
+			return nil, err
+		}
+		e = &entry{dir: dir}
+		m.entries[key] = e
+	}
+	e.refs++
+
+	return &Workspace{manager: m, key: key, entry: e}, nil
+}
+
+func (m *Manager) release(key string, e *entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e.refs--
+	if e.refs > 0 {
+		return
+	}
+
+	if m.retention <= 0 {
+		m.remove(key, e)
+		return
+	}
+	e.expiry = time.AfterFunc(m.retention, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		// Taymer kutayotganda qayta Acquire qilingan bo'lishi mumkin
+		if m.entries[key] == e && e.refs == 0 {
+			m.remove(key, e)
+		}
+	})
+}
+
+// remove papkani o'chiradi; m.mu ushlangan bo'lishi kerak
+func (m *Manager) remove(key string, e *entry) {
+	if e.expiry != nil {
+		e.expiry.Stop()
+		e.expiry = nil
+	}
+	delete(m.entries, key)
+	if err := os.RemoveAll(e.dir); err != nil {
+		log.Printf("Workspace o'chirilmadi (%s): %v", e.dir, err)
+	}
+}
+
+// evictIdle hech bir job ishlatmayotgan (retention kutayotgan) papkalarni o'chiradi
+func (m *Manager) evictIdle() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, e := range m.entries {
+		if e.refs == 0 {
+			m.remove(key, e)
+		}
+	}
+}
+
+// CheckSpace need bayt yozilgandan keyin ham diskda minFree qolishini tekshiradi
+func (m *Manager) CheckSpace(need int64) error {
+	free, err := freeSpace(m.root)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return err
+	}
+
+	if need < 0 {
+		need = 0
+	}
+	if free < uint64(need)+m.minFree {
+		// Keshda saqlanayotgan manbalar joyni bo'shatish uchun birinchi qurbon
+		m.evictIdle()
+		if free, err = freeSpace(m.root); err != nil {
+			return err
+		}
+	}
+	if free < uint64(need)+m.minFree {
+		return fmt.Errorf("%w: kerak %d MB, bo'sh %d MB", ErrInsufficientSpace,
+			(uint64(need)+m.minFree)>>20, free>>20)
+	}
+	return nil
+}
+
+// Workspace - bitta job uchun videoning ish papkasiga havola
+type Workspace struct {
+	manager *Manager
+	key     string
+	entry   *entry
+	once    sync.Once
+}
+
+// Dir videoning umumiy ish papkasi
+func (w *Workspace) Dir() string {
+	return w.entry.dir
+}
+
+// TempDir job uchun alohida papka (boshqa joblar bilan to'qnashmaydi)
+func (w *Workspace) TempDir(pattern string) (string, error) {
+	return os.MkdirTemp(w.entry.dir, pattern)
+}
+
+// Source manba faylni workspace ichida bir marta yuklab oladi va yo'lini qaytaradi.
+// Bir vaqtda chaqirgan joblar birinchi yuklash tugashini kutadi va shu faylni ishlatadi.
+func (w *Workspace) Source(ctx context.Context, size int64, fetch func(ctx context.Context, dst io.Writer) error) (string, error) {
+	e := w.entry
+	e.sourceMu.Lock()
+	defer e.sourceMu.Unlock()
+
+	if e.source != "" {
+		return e.source, nil
+	}
+
+	if err := w.manager.CheckSpace(size); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(e.dir, "source")
+	file, err := os.Create(path + ".part")
+	if err != nil {
+		return "", err
+	}
+
+	err = fetch(ctx, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".part")
+		return "", err
+	}
+
+	// To'liq yuklanmagan fayl boshqa joblarga ko'rinmasligi uchun
+	if err := os.Rename(path+".part", path); err != nil {
+		return "", err
+	}
+
+	e.source = path
+	return path, nil
+}
+
+// Release workspace'dan foydalanishni tugatadi; oxirgi foydalanuvchidan keyin
+// papka retention o'tgach o'chiriladi
+func (w *Workspace) Release() {
+	w.once.Do(func() {
+		w.manager.release(w.key, w.entry)
+	})
+}